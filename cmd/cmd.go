@@ -5,5 +5,10 @@ import "github.com/urfave/cli/v2"
 // Commands is the list of CLIO commands for the application
 var Commands = []*cli.Command{
 	harvest,
+	schedule,
+	server,
+	validate,
+	record,
+	test,
 	version,
 }
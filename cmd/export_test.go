@@ -0,0 +1,4 @@
+package cmd
+
+// NewHandler exposes newHandler to the tests.
+var NewHandler = newHandler
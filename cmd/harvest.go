@@ -1,21 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/mgjules/harvit/conformer"
+	"github.com/mgjules/harvit/diff"
 	"github.com/mgjules/harvit/harvester"
-	"github.com/mgjules/harvit/json"
 	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/output"
 	"github.com/mgjules/harvit/plan"
+	"github.com/mgjules/harvit/state"
 	"github.com/mgjules/harvit/transformer"
+	"github.com/samber/lo"
 	"github.com/urfave/cli/v2"
 )
 
 var harvest = &cli.Command{
 	Name:      "harvest",
 	Usage:     "Let's harvest some data!",
-	UsageText: "harvit harvest [command options] plan",
+	UsageText: "harvit harvest [command options] plan [plan...]",
+	Description: "Each plan argument can be a plan file, a directory of plan files or a glob pattern.\n" +
+		"When more than one plan is given, a list of results is printed, one per plan.",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:    "debug",
@@ -23,6 +35,53 @@ var harvest = &cli.Command{
 			Usage:   "whether running in PROD or DEBUG mode",
 			EnvVars: []string{"HARVIT_DEBUG"},
 		},
+		&cli.BoolFlag{
+			Name:  "meta",
+			Value: false,
+			Usage: "whether to wrap the result with metadata e.g missing fields",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "file to write the result to, '-' for stdout (default: plan output or stdout)",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "format of the result: json, pretty, ndjson, csv or yaml (default: plan output or json)",
+		},
+		&cli.BoolFlag{
+			Name:  "diff",
+			Value: false,
+			Usage: "whether to output only the changes since the previous diff run of each plan",
+		},
+		&cli.StringFlag{
+			Name:    "state",
+			Value:   ".harvit/state",
+			Usage:   "directory keeping the previous result of each plan for --diff",
+			EnvVars: []string{"HARVIT_STATE"},
+		},
+		&cli.BoolFlag{
+			Name:  "exit-code",
+			Value: false,
+			Usage: "whether to exit with code 2 when --diff found changes",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Value: false,
+			Usage: "whether to fail when a value fails to conform e.g a number that cannot be parsed",
+		},
+		&cli.StringFlag{
+			Name:  "source",
+			Usage: "URL or file to harvest instead of the plan source e.g a saved page or a HAR file",
+		},
+		&cli.IntFlag{
+			Name:    "concurrency",
+			Aliases: []string{"c"},
+			Value:   4,
+			Usage:   "maximum number of plans harvested at the same time",
+			EnvVars: []string{"HARVIT_CONCURRENCY"},
+		},
 	},
 	Action: func(c *cli.Context) error {
 		debug := c.Bool("debug")
@@ -31,54 +90,494 @@ var harvest = &cli.Command{
 			return fmt.Errorf("failed to create logger: %w", err)
 		}
 
-		planFile := c.Args().Get(0)
-		if planFile == "" {
-			planFile = "plan.yml"
+		args := c.Args().Slice()
+		if len(args) == 0 {
+			args = []string{"plan.yml"}
 		}
 
-		plan, err := plan.Load(planFile)
+		planFiles, err := resolvePlanFiles(args)
 		if err != nil {
-			return fmt.Errorf("failed to load plan: %w", err)
+			return err
+		}
+
+		var store state.Store
+		if c.Bool("diff") {
+			if store, err = state.NewFile(c.String("state")); err != nil {
+				return fmt.Errorf("failed to create state store: %w", err)
+			}
+		}
+
+		if len(planFiles) == 1 && len(args) == 1 && !isDir(args[0]) {
+			p, err := plan.Load(planFiles[0])
+			if err != nil {
+				return fmt.Errorf("failed to load plan: %w", err)
+			}
+
+			if err := overrideSource(p, c.String("source")); err != nil {
+				return err
+			}
+
+			logger.Log.Debugw("loaded plan", "plan", p)
+
+			res, err := harvestPlan(c.Context, p)
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("strict") {
+				if err := strictError(res); err != nil {
+					return err
+				}
+			}
+
+			var changed bool
+			if store != nil {
+				if changed, err = diffResult(store, planFiles[0], res); err != nil {
+					return err
+				}
+			}
+
+			var out any = res.Data
+			if c.Bool("meta") {
+				out = res
+			}
+
+			if err := writeOutput(c, p.Output, out); err != nil {
+				return err
+			}
+
+			if err := notifyResult(c.Context, p, res); err != nil {
+				return err
+			}
+
+			if store != nil {
+				// Saved last, so that a failed run reports the same changes when run again.
+				if err := saveResult(store, planFiles[0], res); err != nil {
+					return err
+				}
+			}
+
+			if changed && c.Bool("exit-code") {
+				return cli.Exit("", exitCodeChanged)
+			}
+
+			return nil
 		}
 
-		logger.Log.Debugw("loaded plan", "plan", plan)
+		results := harvestPlanFiles(c.Context, planFiles, c.String("source"), c.Int("concurrency"), c.Bool("strict"))
+
+		var failed, changed int
+		for i := range results {
+			if results[i].Error == "" && store != nil {
+				res := &results[i].result
+				if ok, err := diffResult(store, results[i].Plan, res); err != nil {
+					results[i].Error = err.Error()
+				} else if ok {
+					changed++
+				}
+			}
+
+			if results[i].Error == "" && results[i].plan.Output.Path != "" {
+				if err := writeResult(c.Context, results[i].plan.Output, results[i].Data); err != nil {
+					results[i].Error = fmt.Sprintf("failed to write plan output: %v", err)
+				}
+			}
+
+			if results[i].Error == "" {
+				if err := notifyResult(c.Context, results[i].plan, &results[i].result); err != nil {
+					results[i].Error = err.Error()
+				}
+			}
+
+			if results[i].Error == "" && store != nil {
+				if err := saveResult(store, results[i].Plan, &results[i].result); err != nil {
+					results[i].Error = err.Error()
+				}
+			}
+
+			if results[i].Error != "" {
+				failed++
+			}
+
+			if !c.Bool("meta") {
+				results[i].Meta = nil
+			}
+		}
+
+		if err := writeOutput(c, plan.Output{}, results); err != nil {
+			return err
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to harvest %d of %d plans", failed, len(results))
+		}
+
+		if changed > 0 && c.Bool("exit-code") {
+			return cli.Exit("", exitCodeChanged)
+		}
+
+		return nil
+	},
+}
+
+// exitCodeChanged is the exit code used by --exit-code when --diff found changes.
+const exitCodeChanged = 2
+
+// diffResult replaces the data of a result with its changes since the previous result
+// of the plan saved in a store. It reports whether anything changed.
+// The result is only saved by saveResult, once written and notified.
+func diffResult(store state.Store, planFile string, res *result) (bool, error) {
+	key := state.Key(planFile)
+
+	previous, found, err := store.Load(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to load previous result: %w", err)
+	}
+
+	if !found {
+		// Report every field as added on the first run.
+		previous = map[string]any{}
+	}
+
+	changes, err := diff.Compare(previous, res.conformed)
+	if err != nil {
+		return false, fmt.Errorf("failed to diff result: %w", err)
+	}
+
+	res.Data = changes
+
+	return len(changes) > 0, nil
+}
+
+// saveResult saves the conformed data of a result in a store, as the previous result of the next diff.
+func saveResult(store state.Store, planFile string, res *result) error {
+	if err := store.Save(state.Key(planFile), res.conformed); err != nil {
+		return fmt.Errorf("failed to save result: %w", err)
+	}
+
+	return nil
+}
+
+// writeOutput writes a result to the sink selected by the command flags,
+// falling back to the plan output.
+func writeOutput(c *cli.Context, o plan.Output, v any) error {
+	if c.IsSet("format") {
+		o.Format = c.String("format")
+	}
+
+	if c.IsSet("output") {
+		o.Path = c.String("output")
+	}
+
+	return writeResult(c.Context, o, v)
+}
+
+// writeResult writes a result to the sink of an output.
+func writeResult(ctx context.Context, o plan.Output, v any) error {
+	sink, err := output.New(o.Format, o.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+
+	return writeSink(ctx, sink, v)
+}
+
+// appendResult appends a result to the sink of an output, see output.NewAppend.
+func appendResult(ctx context.Context, o plan.Output, v any) error {
+	sink, err := output.NewAppend(o.Format, o.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+
+	return writeSink(ctx, sink, v)
+}
+
+func writeSink(ctx context.Context, sink output.Sink, v any) error {
+	if err := sink.Write(ctx, v); err != nil {
+		sink.Close()
+
+		return err
+	}
+
+	return sink.Close()
+}
+
+// notifyResult sends the data of a result to the webhook of a plan, if any.
+// The notify condition applies to the transformed result, even when the data holds its changes,
+// and a diffed result without changes is not sent.
+func notifyResult(ctx context.Context, p *plan.Plan, res *result) error {
+	if p.Notify == nil {
+		return nil
+	}
+
+	if changes, diffed := res.Data.([]diff.Change); diffed && len(changes) == 0 {
+		logger.Log.Debugw("skipping notification, nothing changed", "source", p.Source)
+
+		return nil
+	}
+
+	webhook, err := output.NewWebhook(*p.Notify)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	if err := webhook.WriteIf(ctx, res.transformed, res.Data); err != nil {
+		return fmt.Errorf("failed to notify webhook: %w", err)
+	}
+
+	return webhook.Close()
+}
+
+// result wraps the transformed data with its metadata.
+type result struct {
+	Data any   `json:"data"`
+	Meta *meta `json:"meta,omitempty"`
+	// Conformed data before transformation, used for diffing.
+	conformed map[string]any
+	// Transformed data, kept when the data is replaced by its changes.
+	transformed any
+}
+
+// meta describes how the data was harvested.
+type meta struct {
+	// Fields that harvested nothing.
+	Missing []string `json:"missing"`
+	// Values that failed to conform.
+	Errors conformer.Report `json:"errors,omitempty"`
+}
 
-		h, err := harvester.New(plan.Type)
+// strictError returns an error when some values of a result failed to conform.
+func strictError(res *result) error {
+	if len(res.Meta.Errors) == 0 {
+		return nil
+	}
+
+	first := res.Meta.Errors[0]
+
+	return fmt.Errorf(
+		"failed to conform %d values, first %s: %s", len(res.Meta.Errors), first.Field, first.Error,
+	)
+}
+
+// batchResult is the result of a single plan harvested in a batch.
+type batchResult struct {
+	Plan  string `json:"plan"`
+	Error string `json:"error,omitempty"`
+	result
+	plan *plan.Plan
+}
+
+// harvestPlan runs a plan through the harvester, the conformer and the transformer.
+func harvestPlan(ctx context.Context, p *plan.Plan) (*result, error) {
+	h, err := harvester.New(p.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create harvester: %w", err)
+	}
+
+	harvested, err := h.Harvest(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to harvest data: %w", err)
+	}
+
+	logger.Log.Debugw("harvesting done", "harvested", harvested)
+
+	missing := harvester.Missing(harvested)
+	if len(missing) > 0 {
+		logger.Log.Warnw("some fields harvested nothing", "source", p.Source, "missing", missing)
+	}
+
+	conformed, report, err := conformer.Conform(ctx, p.ResultFields(), harvested)
+	if err != nil {
+		return nil, fmt.Errorf("failed to conform data: %w", err)
+	}
+
+	if len(report) > 0 {
+		logger.Log.Warnw("some values failed to conform", "source", p.Source, "errors", report)
+	}
+
+	logger.Log.Debugw("conforming done", "conformed", conformed)
+
+	var transformed any = conformed
+	if p.Transformer != "" {
+		transformed, err = transformer.Transform(ctx, p.Transformer, p.ResultFields(), conformed)
 		if err != nil {
-			return fmt.Errorf("failed to create harvester: %w", err)
+			return nil, fmt.Errorf("failed to transform data: %w", err)
 		}
 
-		harvested, err := h.Harvest(c.Context, plan)
+		logger.Log.Debugw("transformation done", "transformed", transformed)
+	}
+
+	return &result{
+		Data:        transformed,
+		Meta:        &meta{Missing: missing, Errors: report},
+		conformed:   conformed,
+		transformed: transformed,
+	}, nil
+}
+
+// harvestPlanFiles harvests plans with a bounded pool of workers.
+// Website plans share a single browser, each harvest running in its own tab.
+// When strict, plans with values that failed to conform fail.
+func harvestPlanFiles(
+	ctx context.Context, planFiles []string, source string, concurrency int, strict bool,
+) []batchResult {
+	results := make([]batchResult, len(planFiles))
+	plans := make([]*plan.Plan, len(planFiles))
+
+	var needsBrowser bool
+	for i := range planFiles {
+		results[i].Plan = planFiles[i]
+
+		p, err := plan.Load(planFiles[i])
 		if err != nil {
-			return fmt.Errorf("failed to harvest data: %w", err)
+			results[i].Error = fmt.Sprintf("failed to load plan: %v", err)
+
+			continue
 		}
 
-		logger.Log.Debugw("harvesting done", "harvested", harvested)
+		if err := overrideSource(p, source); err != nil {
+			results[i].Error = err.Error()
 
-		conformed, err := conformer.Conform(c.Context, plan.Fields, harvested)
+			continue
+		}
+
+		plans[i] = p
+		results[i].plan = p
+		needsBrowser = needsBrowser || p.Type == harvester.TypeWebsite
+	}
+
+	if needsBrowser {
+		browserCtx, cancel, err := harvester.NewBrowser(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to conform data: %w", err)
+			logger.Log.Warnw("failed to start shared browser, website plans will start their own", "error", err)
+		} else {
+			defer cancel()
+			ctx = browserCtx
 		}
+	}
 
-		logger.Log.Debugw("conforming done", "conformed", conformed)
+	jobs := make(chan int)
 
-		var transformed any = conformed
-		if plan.Transformer != "" {
-			transformed, err = transformer.Transform(c.Context, plan.Transformer, plan.Fields, conformed)
-			if err != nil {
-				return fmt.Errorf("failed to transform data: %w", err)
+	var wg sync.WaitGroup
+	for w := 0; w < lo.Max([]int{concurrency, 1}); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				res, err := harvestPlan(ctx, plans[i])
+				if err != nil {
+					logger.Log.Errorw("failed to harvest plan", "plan", planFiles[i], "error", err)
+					results[i].Error = err.Error()
+
+					continue
+				}
+
+				results[i].result = *res
+
+				if strict {
+					if err := strictError(res); err != nil {
+						logger.Log.Errorw("failed to harvest plan", "plan", planFiles[i], "error", err)
+						results[i].Error = err.Error()
+					}
+				}
+			}
+		}()
+	}
+
+	for i := range plans {
+		if plans[i] != nil {
+			jobs <- i
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return results
+}
+
+// overrideSource replaces the source of a plan, if a source is given.
+// A file path is harvested as a saved page, or replayed when it is a HAR file.
+// The plan source is kept when replaying, so that it is looked up in the archive.
+func overrideSource(p *plan.Plan, source string) error {
+	if source == "" {
+		return nil
+	}
+
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" && u.Scheme != "file" {
+		p.Source = source
+
+		return nil
+	}
+
+	path := strings.TrimPrefix(source, "file://")
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source %q: %w", source, err)
+	}
+
+	if _, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("failed to read source: %w", err)
+	}
+
+	if harvester.IsArchive(abs) {
+		p.Archive = abs
+
+		return nil
+	}
+
+	p.Source = (&url.URL{Scheme: "file", Path: abs}).String()
+
+	return nil
+}
+
+// resolvePlanFiles expands plan files, directories of plan files and glob patterns.
+func resolvePlanFiles(args []string) ([]string, error) {
+	var planFiles []string
+	for _, arg := range args {
+		if isDir(arg) {
+			var matches []string
+			for _, ext := range []string{"*.yml", "*.yaml"} {
+				m, err := filepath.Glob(filepath.Join(arg, ext))
+				if err != nil {
+					return nil, fmt.Errorf("failed to list plans in %q: %w", arg, err)
+				}
+
+				matches = append(matches, m...)
 			}
 
-			logger.Log.Debugw("transformation done", "transformed", transformed)
+			sort.Strings(matches)
+			planFiles = append(planFiles, matches...)
+
+			continue
 		}
 
-		marshaled, err := json.Marshal(transformed)
+		matches, err := filepath.Glob(arg)
 		if err != nil {
-			return fmt.Errorf("failed to marshal transformed data: %w", err)
+			return nil, fmt.Errorf("failed to match plans %q: %w", arg, err)
 		}
 
-		fmt.Println(string(marshaled))
+		if len(matches) == 0 {
+			// Let plan.Load report the missing file.
+			matches = []string{arg}
+		}
 
-		return nil
-	},
+		planFiles = append(planFiles, matches...)
+	}
+
+	planFiles = lo.Uniq(planFiles)
+	if len(planFiles) == 0 {
+		return nil, fmt.Errorf("no plan found in %v", args)
+	}
+
+	return planFiles, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.IsDir()
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	"github.com/urfave/cli/v2"
+)
+
+var fixturesFlag = &cli.StringFlag{
+	Name:    "fixtures",
+	Value:   "fixtures",
+	Usage:   "directory holding the recorded responses and the golden result of each plan",
+	EnvVars: []string{"HARVIT_FIXTURES"},
+}
+
+var record = &cli.Command{
+	Name:      "record",
+	Usage:     "Records the responses and the result of plans as test fixtures",
+	UsageText: "harvit record [command options] plan [plan...]",
+	Description: "Each plan argument can be a plan file, a directory of plan files or a glob pattern.\n" +
+		"Each plan is harvested live while its responses are recorded into <fixtures>/<name>.har\n" +
+		"and its result is written to <fixtures>/<name>.golden.json,\n" +
+		"the name of a plan being its file name without extension.\n" +
+		"Run 'harvit test' to replay the fixtures and compare the results.",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "debug",
+			Value:   false,
+			Usage:   "whether running in PROD or DEBUG mode",
+			EnvVars: []string{"HARVIT_DEBUG"},
+		},
+		fixturesFlag,
+	},
+	Action: func(c *cli.Context) error {
+		debug := c.Bool("debug")
+
+		if _, err := logger.New(debug); err != nil {
+			return fmt.Errorf("failed to create logger: %w", err)
+		}
+
+		args := c.Args().Slice()
+		if len(args) == 0 {
+			args = []string{"plan.yml"}
+		}
+
+		planFiles, err := resolvePlanFiles(args)
+		if err != nil {
+			return err
+		}
+
+		dir := c.String("fixtures")
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create fixtures directory: %w", err)
+		}
+
+		for _, planFile := range planFiles {
+			p, err := plan.Load(planFile)
+			if err != nil {
+				return fmt.Errorf("failed to load plan %q: %w", planFile, err)
+			}
+
+			rec := harvester.NewRecorder()
+
+			res, err := harvestPlan(harvester.WithRecorder(c.Context, rec), p)
+			if err != nil {
+				return fmt.Errorf("failed to record plan %q: %w", planFile, err)
+			}
+
+			archivePath, goldenPath := fixturePaths(dir, planFile)
+
+			if err := rec.Archive().Save(archivePath); err != nil {
+				return err
+			}
+
+			if err := writeGolden(goldenPath, res.Data); err != nil {
+				return err
+			}
+
+			logger.Log.Infow("recorded plan", "plan", planFile, "archive", archivePath, "golden", goldenPath)
+		}
+
+		return nil
+	},
+}
+
+// fixturePaths returns the paths of the archive and of the golden result of a plan.
+func fixturePaths(dir, planFile string) (string, string) {
+	name := strings.TrimSuffix(filepath.Base(planFile), filepath.Ext(planFile))
+
+	return filepath.Join(dir, name+".har"), filepath.Join(dir, name+".golden.json")
+}
+
+func writeGolden(path string, v any) error {
+	marshaled, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal golden result: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(marshaled, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write golden result: %w", err)
+	}
+
+	return nil
+}
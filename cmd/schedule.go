@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	"github.com/robfig/cron/v3"
+	"github.com/urfave/cli/v2"
+)
+
+var schedule = &cli.Command{
+	Name:      "schedule",
+	Usage:     "Harvest plans on their schedule until stopped",
+	UsageText: "harvit schedule [command options] plan [plan...]",
+	Description: "Each plan argument can be a plan file, a directory of plan files or a glob pattern.\n" +
+		"Plans are run on the cron expression of their schedule, e.g '*/15 * * * *' or '@every 1h'.\n" +
+		"A run is skipped while the previous run of the same plan is still going.",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "debug",
+			Value:   false,
+			Usage:   "whether running in PROD or DEBUG mode",
+			EnvVars: []string{"HARVIT_DEBUG"},
+		},
+		&cli.BoolFlag{
+			Name:  "meta",
+			Value: false,
+			Usage: "whether to wrap the result with metadata e.g missing fields",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Value: false,
+			Usage: "whether to fail when a value fails to conform e.g a number that cannot be parsed",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		debug := c.Bool("debug")
+
+		if _, err := logger.New(debug); err != nil {
+			return fmt.Errorf("failed to create logger: %w", err)
+		}
+
+		args := c.Args().Slice()
+		if len(args) == 0 {
+			args = []string{"plan.yml"}
+		}
+
+		planFiles, err := resolvePlanFiles(args)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cronLogger := cron.PrintfLogger(stdlog.New(logger.Log.Writer(), "", 0))
+		scheduler := cron.New(cron.WithChain(
+			cron.Recover(cronLogger),
+			cron.SkipIfStillRunning(cronLogger),
+		))
+
+		for _, planFile := range planFiles {
+			p, err := plan.Load(planFile)
+			if err != nil {
+				return fmt.Errorf("failed to load plan %q: %w", planFile, err)
+			}
+
+			if p.Schedule == "" {
+				logger.Log.Warnw("skipping plan without schedule", "plan", planFile)
+
+				continue
+			}
+
+			run := scheduledRun(ctx, planFile, p, c.Bool("meta"), c.Bool("strict"))
+			if _, err := scheduler.AddFunc(p.Schedule, run); err != nil {
+				return fmt.Errorf("failed to schedule plan %q: %w", planFile, err)
+			}
+
+			logger.Log.Infow("scheduled plan", "plan", planFile, "schedule", p.Schedule)
+		}
+
+		if len(scheduler.Entries()) == 0 {
+			return fmt.Errorf("no plan with a schedule in %v", args)
+		}
+
+		scheduler.Start()
+
+		<-ctx.Done()
+
+		logger.Log.Infow("stopping scheduler, waiting for running plans")
+		<-scheduler.Stop().Done()
+
+		return nil
+	},
+}
+
+// scheduledRun returns a job harvesting a plan and writing its result to the plan output.
+// ndjson and csv results are appended to the output file, other formats overwrite it.
+// When strict, results with values that failed to conform are not written.
+func scheduledRun(ctx context.Context, planFile string, p *plan.Plan, withMeta, strict bool) func() {
+	return func() {
+		start := time.Now()
+
+		logger.Log.Infow("running plan", "plan", planFile)
+
+		res, err := harvestPlan(ctx, p)
+		if err != nil {
+			logger.Log.Errorw("failed to harvest plan", "plan", planFile, "duration", time.Since(start), "error", err)
+
+			return
+		}
+
+		if strict {
+			if err := strictError(res); err != nil {
+				logger.Log.Errorw("failed to harvest plan", "plan", planFile, "duration", time.Since(start), "error", err)
+
+				return
+			}
+		}
+
+		var out any = res.Data
+		if withMeta {
+			out = res
+		}
+
+		if err := appendResult(ctx, p.Output, out); err != nil {
+			logger.Log.Errorw("failed to write plan output", "plan", planFile, "error", err)
+
+			return
+		}
+
+		if err := notifyResult(ctx, p, res); err != nil {
+			logger.Log.Errorw("failed to notify plan result", "plan", planFile, "error", err)
+
+			return
+		}
+
+		logger.Log.Infow("plan done", "plan", planFile, "duration", time.Since(start), "missing", res.Meta.Missing)
+	}
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	maxPlanSize     = 1 << 20
+	shutdownTimeout = 30 * time.Second
+)
+
+// Job statuses.
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+var server = &cli.Command{
+	Name:      "server",
+	Usage:     "Serve harvesting over HTTP",
+	UsageText: "harvit server [command options] [plan...]",
+	Description: "Each plan argument can be a plan file, a directory of plan files or a glob pattern.\n" +
+		"These plans are preloaded and can be run by name, the name of a plan being its file name without extension.\n\n" +
+		"Endpoints:\n" +
+		"   POST /harvest        harvests the plan in the body (YAML or JSON) or the preloaded plan named by ?plan=\n" +
+		"   POST /jobs           same as /harvest but returns a job to poll instead of waiting for the result\n" +
+		"   GET  /jobs/{id}      returns the status and the result of a job\n" +
+		"   GET  /plans          lists the preloaded plans\n" +
+		"   GET  /healthz        returns 200 when the server is up",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "debug",
+			Value:   false,
+			Usage:   "whether running in PROD or DEBUG mode",
+			EnvVars: []string{"HARVIT_DEBUG"},
+		},
+		&cli.StringFlag{
+			Name:    "addr",
+			Value:   ":8080",
+			Usage:   "address to listen on",
+			EnvVars: []string{"HARVIT_ADDR"},
+		},
+		&cli.DurationFlag{
+			Name:    "job-ttl",
+			Value:   time.Hour,
+			Usage:   "how long the result of a finished job is kept",
+			EnvVars: []string{"HARVIT_JOB_TTL"},
+		},
+	},
+	Action: func(c *cli.Context) error {
+		debug := c.Bool("debug")
+
+		if _, err := logger.New(debug); err != nil {
+			return fmt.Errorf("failed to create logger: %w", err)
+		}
+
+		plans := make(map[string]*plan.Plan)
+		if c.Args().Len() > 0 {
+			planFiles, err := resolvePlanFiles(c.Args().Slice())
+			if err != nil {
+				return err
+			}
+
+			for _, planFile := range planFiles {
+				p, err := plan.Load(planFile)
+				if err != nil {
+					return fmt.Errorf("failed to load plan %q: %w", planFile, err)
+				}
+
+				name := strings.TrimSuffix(filepath.Base(planFile), filepath.Ext(planFile))
+				plans[name] = p
+
+				logger.Log.Infow("preloaded plan", "name", name, "plan", planFile)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		srv := &http.Server{
+			Addr:              c.String("addr"),
+			Handler:           newHandler(ctx, plans, c.Duration("job-ttl")),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		errs := make(chan error, 1)
+		go func() {
+			logger.Log.Infow("listening", "addr", srv.Addr)
+			errs <- srv.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errs:
+			return fmt.Errorf("failed to serve: %w", err)
+		case <-ctx.Done():
+		}
+
+		logger.Log.Infow("shutting down server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down server: %w", err)
+		}
+
+		return nil
+	},
+}
+
+// job is a plan harvested in the background.
+type job struct {
+	ID     string  `json:"id"`
+	Status string  `json:"status"`
+	Error  string  `json:"error,omitempty"`
+	Result *result `json:"result,omitempty"`
+	// When the job finished, zero while running.
+	finished time.Time
+}
+
+// expired reports whether a job finished more than ttl ago.
+func (j *job) expired(ttl time.Duration) bool {
+	return !j.finished.IsZero() && time.Since(j.finished) > ttl
+}
+
+// handler serves harvesting over HTTP.
+type handler struct {
+	// ctx bounds the background jobs.
+	ctx   context.Context
+	plans map[string]*plan.Plan
+	// jobTTL is how long finished jobs are kept.
+	jobTTL time.Duration
+
+	mu   sync.RWMutex
+	jobs map[string]*job
+}
+
+func newHandler(ctx context.Context, plans map[string]*plan.Plan, jobTTL time.Duration) http.Handler {
+	h := &handler{
+		ctx:    ctx,
+		plans:  plans,
+		jobTTL: jobTTL,
+		jobs:   make(map[string]*job),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/harvest", h.harvest)
+	mux.HandleFunc("/jobs", h.createJob)
+	mux.HandleFunc("/jobs/", h.getJob)
+	mux.HandleFunc("/plans", h.listPlans)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	return mux
+}
+
+func (h *handler) harvest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+		return
+	}
+
+	p, status, err := h.requestPlan(r)
+	if err != nil {
+		writeError(w, status, err)
+
+		return
+	}
+
+	res, err := harvestPlan(r.Context(), p)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *handler) createJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+		return
+	}
+
+	p, status, err := h.requestPlan(r)
+	if err != nil {
+		writeError(w, status, err)
+
+		return
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	j := &job{ID: id, Status: JobRunning}
+	// The job is updated by the harvest, respond with a copy.
+	created := *j
+
+	h.mu.Lock()
+	h.evictJobs()
+	h.jobs[id] = j
+	h.mu.Unlock()
+
+	go func() {
+		res, err := harvestPlan(h.ctx, p)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		j.finished = time.Now()
+
+		if err != nil {
+			logger.Log.Errorw("failed to harvest job", "id", id, "error", err)
+			j.Status = JobFailed
+			j.Error = err.Error()
+
+			return
+		}
+
+		j.Status = JobDone
+		j.Result = res
+	}()
+
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, created)
+}
+
+func (h *handler) getJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	j, found := h.jobs[id]
+	if !found || j.expired(h.jobTTL) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown job: %s", id))
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, j)
+}
+
+// evictJobs removes the jobs finished for longer than the job TTL.
+// The caller must hold the lock.
+func (h *handler) evictJobs() {
+	for id, j := range h.jobs {
+		if j.expired(h.jobTTL) {
+			delete(h.jobs, id)
+		}
+	}
+}
+
+func (h *handler) listPlans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+		return
+	}
+
+	names := make([]string, 0, len(h.plans))
+	for name := range h.plans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writeJSON(w, http.StatusOK, names)
+}
+
+// requestPlan returns the preloaded plan named by the plan query parameter
+// or parses the plan in the request body.
+func (h *handler) requestPlan(r *http.Request) (*plan.Plan, int, error) {
+	if name := r.URL.Query().Get("plan"); name != "" {
+		p, found := h.plans[name]
+		if !found {
+			return nil, http.StatusNotFound, fmt.Errorf("unknown plan: %s", name)
+		}
+
+		return p, http.StatusOK, nil
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxPlanSize))
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	p, err := plan.Parse(raw)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Plans sent over HTTP must not read the files of the server.
+	if u, err := url.Parse(p.Source); p.Archive != "" || err != nil || u.Scheme == "file" {
+		return nil, http.StatusBadRequest, errors.New("local sources are not allowed")
+	}
+
+	if p.Transformer != "" {
+		return nil, http.StatusBadRequest, errors.New("transformers are not allowed, use a preloaded plan")
+	}
+
+	return p, http.StatusOK, nil
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		logger.Log.Errorw("failed to marshal response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(marshaled); err != nil {
+		logger.Log.Errorw("failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,175 @@
+package cmd_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/mgjules/harvit/cmd"
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", Ordered, func() {
+	var (
+		source *httptest.Server
+		srv    *httptest.Server
+	)
+
+	BeforeAll(func() {
+		_, err := logger.New(false)
+		Expect(err).To(BeNil())
+
+		source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<!DOCTYPE html><html><body><h1>Gopher</h1></body></html>`)
+		}))
+
+		plans := map[string]*plan.Plan{
+			"jobs": {
+				Source: source.URL,
+				Type:   harvester.TypeHTML,
+				Fields: []plan.Field{{Name: "title", Type: converter.TypeText, Selector: "h1"}},
+			},
+			"alpha": {Source: source.URL, Type: harvester.TypeHTML},
+		}
+
+		srv = httptest.NewServer(cmd.NewHandler(context.Background(), plans, time.Hour))
+	})
+
+	AfterAll(func() {
+		srv.Close()
+		source.Close()
+	})
+
+	requestTo := func(base, method, path, body string) (int, map[string]any) {
+		req, err := http.NewRequestWithContext(context.Background(), method, base+path, strings.NewReader(body))
+		Expect(err).To(BeNil())
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+
+		var decoded any
+		Expect(json.NewDecoder(resp.Body).Decode(&decoded)).To(Succeed())
+
+		object, _ := decoded.(map[string]any)
+		if list, ok := decoded.([]any); ok {
+			object = map[string]any{"list": list}
+		}
+
+		return resp.StatusCode, object
+	}
+
+	request := func(method, path, body string) (int, map[string]any) {
+		return requestTo(srv.URL, method, path, body)
+	}
+
+	bodyPlan := func(extra string) string {
+		return "source: " + source.URL + "\ntype: html\n" + extra + "fields:\n  - name: title\n    selector: h1\n"
+	}
+
+	It("should harvest a plan sent in the body", func() {
+		status, body := request(http.MethodPost, "/harvest", bodyPlan(""))
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("data", map[string]any{"title": "Gopher"}))
+	})
+
+	It("should harvest a preloaded plan", func() {
+		status, body := request(http.MethodPost, "/harvest?plan=jobs", "")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("data", map[string]any{"title": "Gopher"}))
+	})
+
+	It("should run a job to poll", func() {
+		status, body := request(http.MethodPost, "/jobs?plan=jobs", "")
+		Expect(status).To(Equal(http.StatusAccepted))
+		Expect(body).To(HaveKeyWithValue("status", "running"))
+
+		id, _ := body["id"].(string)
+		Expect(id).NotTo(BeEmpty())
+
+		Eventually(func() map[string]any {
+			status, body := request(http.MethodGet, "/jobs/"+id, "")
+			Expect(status).To(Equal(http.StatusOK))
+
+			return body
+		}).WithTimeout(5 * time.Second).WithPolling(10 * time.Millisecond).Should(SatisfyAll(
+			HaveKeyWithValue("status", "done"),
+			HaveKeyWithValue("result", HaveKeyWithValue("data", map[string]any{"title": "Gopher"})),
+		))
+	})
+
+	It("should evict the finished jobs after their TTL", func() {
+		evicting := httptest.NewServer(cmd.NewHandler(context.Background(), map[string]*plan.Plan{
+			"jobs": {Source: source.URL, Type: harvester.TypeHTML},
+		}, 0))
+		defer evicting.Close()
+
+		status, body := requestTo(evicting.URL, http.MethodPost, "/jobs?plan=jobs", "")
+		Expect(status).To(Equal(http.StatusAccepted))
+
+		id, _ := body["id"].(string)
+
+		Eventually(func() int {
+			status, _ := requestTo(evicting.URL, http.MethodGet, "/jobs/"+id, "")
+
+			return status
+		}).WithTimeout(5 * time.Second).WithPolling(10 * time.Millisecond).Should(Equal(http.StatusNotFound))
+	})
+
+	It("should list the preloaded plans by name", func() {
+		status, body := request(http.MethodGet, "/plans", "")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("list", []any{"alpha", "jobs"}))
+	})
+
+	DescribeTable("should reject invalid requests",
+		func(method, path, body string, expected int, message string) {
+			status, decoded := request(method, path, body)
+			Expect(status).To(Equal(expected))
+			Expect(decoded).To(HaveKeyWithValue("error", ContainSubstring(message)))
+		},
+		Entry("with an unknown job", http.MethodGet, "/jobs/unknown", "", http.StatusNotFound, "unknown job"),
+		Entry("with an unknown plan", http.MethodPost, "/harvest?plan=unknown", "", http.StatusNotFound, "unknown plan"),
+		Entry("with an unknown plan for a job",
+			http.MethodPost, "/jobs?plan=unknown", "", http.StatusNotFound, "unknown plan",
+		),
+		Entry("harvesting with GET",
+			http.MethodGet, "/harvest?plan=jobs", "", http.StatusMethodNotAllowed, "method not allowed",
+		),
+		Entry("creating a job with GET",
+			http.MethodGet, "/jobs?plan=jobs", "", http.StatusMethodNotAllowed, "method not allowed",
+		),
+		Entry("getting a job with POST",
+			http.MethodPost, "/jobs/unknown", "", http.StatusMethodNotAllowed, "method not allowed",
+		),
+		Entry("listing the plans with POST",
+			http.MethodPost, "/plans", "", http.StatusMethodNotAllowed, "method not allowed",
+		),
+		Entry("with an invalid plan", http.MethodPost, "/harvest", "fields: 42", http.StatusBadRequest, ""),
+		Entry("with a local source", http.MethodPost, "/harvest",
+			strings.Replace(examplePlan(""), "https://example.com", "file:///etc/passwd", 1), http.StatusBadRequest,
+			"local sources are not allowed",
+		),
+		Entry("with an archive", http.MethodPost, "/harvest",
+			examplePlan("archive: /tmp/site.har\n"), http.StatusBadRequest, "local sources are not allowed",
+		),
+		Entry("with a transformer", http.MethodPost, "/jobs",
+			examplePlan("transformer: data\n"), http.StatusBadRequest, "transformers are not allowed",
+		),
+	)
+})
+
+// examplePlan returns a plan harvesting example.com, with extra settings.
+func examplePlan(extra string) string {
+	return "source: https://example.com\n" + extra + "fields:\n  - name: title\n    selector: h1\n"
+}
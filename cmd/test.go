@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/mgjules/harvit/diff"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	"github.com/urfave/cli/v2"
+)
+
+var test = &cli.Command{
+	Name:      "test",
+	Usage:     "Replays the fixtures of plans and compares their results to the golden ones",
+	UsageText: "harvit test [command options] plan [plan...]",
+	Description: "Each plan argument can be a plan file, a directory of plan files or a glob pattern.\n" +
+		"Each plan is harvested from the responses recorded by 'harvit record', without any network access,\n" +
+		"and its result is compared to the golden one. The command fails when any result differs.",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "debug",
+			Value:   false,
+			Usage:   "whether running in PROD or DEBUG mode",
+			EnvVars: []string{"HARVIT_DEBUG"},
+		},
+		fixturesFlag,
+		&cli.BoolFlag{
+			Name:  "update",
+			Value: false,
+			Usage: "whether to replace the golden results with the replayed ones",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		debug := c.Bool("debug")
+
+		if _, err := logger.New(debug); err != nil {
+			return fmt.Errorf("failed to create logger: %w", err)
+		}
+
+		args := c.Args().Slice()
+		if len(args) == 0 {
+			args = []string{"plan.yml"}
+		}
+
+		planFiles, err := resolvePlanFiles(args)
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		for _, planFile := range planFiles {
+			if err := testPlan(c, planFile); err != nil {
+				failed++
+				fmt.Fprintf(c.App.Writer, "FAIL %s\n%v\n", planFile, err)
+
+				continue
+			}
+
+			fmt.Fprintf(c.App.Writer, "ok   %s\n", planFile)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d plans failed", failed, len(planFiles))
+		}
+
+		return nil
+	},
+}
+
+// testPlan replays the archive of a plan and compares the result to the golden one.
+func testPlan(c *cli.Context, planFile string) error {
+	p, err := plan.Load(planFile)
+	if err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	archivePath, goldenPath := fixturePaths(c.String("fixtures"), planFile)
+
+	if p.Archive, err = filepath.Abs(archivePath); err != nil {
+		return fmt.Errorf("failed to resolve archive: %w", err)
+	}
+
+	res, err := harvestPlan(c.Context, p)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("update") {
+		return writeGolden(goldenPath, res.Data)
+	}
+
+	raw, err := os.ReadFile(filepath.Clean(goldenPath))
+	if err != nil {
+		return fmt.Errorf("failed to read golden result: %w", err)
+	}
+
+	var golden any
+	if err := json.Unmarshal(raw, &golden); err != nil {
+		return fmt.Errorf("failed to unmarshal golden result: %w", err)
+	}
+
+	// Compare the JSON representations, as the golden result was read from JSON.
+	got, err := json.Normalize(res.Data)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(golden, got) {
+		return nil
+	}
+
+	changes, err := diff.Compare(golden, got)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		return fmt.Errorf("result differs from %s: items are in a different order", goldenPath)
+	}
+
+	pretty, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal changes: %w", err)
+	}
+
+	return fmt.Errorf("result differs from %s:\n%s", goldenPath, pretty)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/mgjules/harvit/linter"
+	"github.com/urfave/cli/v2"
+)
+
+var validate = &cli.Command{
+	Name:      "validate",
+	Usage:     "Checks plans for mistakes without harvesting",
+	UsageText: "harvit validate plan [plan...]",
+	Description: "Each plan argument can be a plan file, a directory of plan files or a glob pattern.\n" +
+		"Besides the validation done when loading a plan, selectors, regexes, timezones, formats,\n" +
+		"transformers, notifications and schedules are checked, as well as duplicate field names.\n" +
+		"Problems are printed as 'file:line: path: message' and the command fails when any is found.",
+	Action: func(c *cli.Context) error {
+		args := c.Args().Slice()
+		if len(args) == 0 {
+			args = []string{"plan.yml"}
+		}
+
+		planFiles, err := resolvePlanFiles(args)
+		if err != nil {
+			return err
+		}
+
+		var problems int
+		for _, planFile := range planFiles {
+			found, err := linter.LintFile(planFile)
+			if err != nil {
+				return fmt.Errorf("failed to validate plan %q: %w", planFile, err)
+			}
+
+			for i := range found {
+				fmt.Fprintf(c.App.Writer, "%s:%s\n", planFile, found[i])
+			}
+
+			problems += len(found)
+		}
+
+		if problems > 0 {
+			return fmt.Errorf("found %d problems in %d plans", problems, len(planFiles))
+		}
+
+		return nil
+	},
+}
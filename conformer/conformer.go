@@ -2,8 +2,8 @@ package conformer
 
 import (
 	"context"
+	"fmt"
 	"regexp"
-	"strings"
 
 	"github.com/go-playground/mold/v4/modifiers"
 	"github.com/mgjules/harvit/converter"
@@ -12,79 +12,300 @@ import (
 	"github.com/samber/lo"
 )
 
+// modifier sanitizes values before conversion.
+var modifier = modifiers.New()
+
+// Regex no-match modes.
+const (
+	NoMatchNull  = "null"
+	NoMatchKeep  = "keep"
+	NoMatchError = "error"
+)
+
+// FieldError is a harvested value that failed to conform.
+type FieldError struct {
+	// Path of the field e.g "price" or "jobs[2].salary".
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// Report lists the harvested values that failed to conform.
+type Report []FieldError
+
 // Conform conforms any harvested data to a set of rules.
-func Conform(ctx context.Context, fields []plan.Field, data map[string]any) (map[string]any, error) {
+// The fields must be compiled, see plan.Field.Compile.
+// Values that fail to conform are set to nil and listed in the report.
+func Conform(ctx context.Context, fields []plan.Field, data map[string]any) (map[string]any, Report, error) {
+	report := make(Report, 0)
+
+	conformed, err := conformRecord(ctx, "", fields, data, make(fieldConformers), &report)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conformed, report, nil
+}
+
+func conformRecord(
+	ctx context.Context, prefix string, fields []plan.Field, data map[string]any, fcs fieldConformers, report *Report,
+) (map[string]any, error) {
 	conformed := make(map[string]any)
 
-	for name, raw := range data {
-		field, found := lo.Find(fields, func(f plan.Field) bool {
-			return f.Name == name
-		})
+	for i := range fields {
+		field := &fields[i]
 
+		raw, found := data[field.Name]
 		if !found {
+			switch {
+			case field.IsMultiple():
+				conformed[field.Name] = []any{}
+			case field.IsSingle():
+				conformed[field.Name] = nil
+			}
+
 			continue
 		}
 
+		path := prefix + field.Name
+		raw = shape(field, raw)
+
 		switch r := raw.(type) {
+		case nil:
+			conformed[field.Name] = nil
 		case string:
-			conformed[name] = conformField(ctx, &field, r)
+			fc, err := fcs.get(field)
+			if err != nil {
+				return nil, err
+			}
+
+			conformed[field.Name] = fc.conformValue(ctx, path, r, report)
 		case []string:
-			conformed[name] = make([]any, 0)
-			for i := range r {
-				conformed[name] = append( //nolint:forcetypeassert
-					conformed[name].([]any),
-					conformField(ctx, &field, r[i]),
-				)
+			fc, err := fcs.get(field)
+			if err != nil {
+				return nil, err
+			}
+
+			values := make([]any, 0, len(r))
+			for j := range r {
+				values = append(values, fc.conformValue(ctx, fmt.Sprintf("%s[%d]", path, j), r[j], report))
+			}
+
+			conformed[field.Name] = values
+		case map[string]any:
+			record, err := conformRecord(ctx, path+".", field.Fields, r, fcs, report)
+			if err != nil {
+				return nil, err
+			}
+
+			conformed[field.Name] = record
+		case []map[string]any:
+			records := make([]any, 0, len(r))
+			for j := range r {
+				record, err := conformRecord(ctx, fmt.Sprintf("%s[%d].", path, j), field.Fields, r[j], fcs, report)
+				if err != nil {
+					return nil, err
+				}
+
+				records = append(records, record)
 			}
+
+			conformed[field.Name] = records
 		}
 	}
 
 	return conformed, nil
 }
 
-func conformField(ctx context.Context, field *plan.Field, val string) any {
-	var err error
-
-	if field.Regex != "" {
-		var re *regexp.Regexp
-		re, err = regexp.Compile(field.Regex)
-		if err != nil {
-			logger.Log.Warnw("failed to compile regex", "name", field.Name, "regex", field.Regex, "error", err)
+// shape returns the harvested values of a field in the shape it declares,
+// as values merged across pages may hold a list for a single field and conversely.
+func shape(field *plan.Field, raw any) any {
+	switch r := raw.(type) {
+	case string:
+		if field.IsMultiple() {
+			return []string{r}
+		}
+	case []string:
+		if field.IsSingle() {
+			if len(r) == 0 {
+				return nil
+			}
 
-			return val
+			return r[0]
 		}
+	}
+
+	return raw
+}
+
+// fieldConformer conforms the values of a compiled field.
+type fieldConformer struct {
+	field     *plan.Field
+	re        *regexp.Regexp
+	converter converter.Converter
+	// Modifier tags sanitizing a value before conversion.
+	tags string
+}
+
+// fieldConformers holds the conformers of the fields conformed so far,
+// so that each field builds its converter once for every record.
+type fieldConformers map[*plan.Field]*fieldConformer
+
+func (fcs fieldConformers) get(field *plan.Field) (*fieldConformer, error) {
+	if fc, found := fcs[field]; found {
+		return fc, nil
+	}
+
+	fc, err := newFieldConformer(field)
+	if err != nil {
+		return nil, err
+	}
+
+	fcs[field] = fc
+
+	return fc, nil
+}
+
+func newFieldConformer(field *plan.Field) (*fieldConformer, error) {
+	if (field.Regex != "" && field.Regexp() == nil) || (field.Timezone != "" && field.Location() == nil) {
+		return nil, fmt.Errorf("field %s is not compiled", field.Name)
+	}
 
-		matches := re.FindStringSubmatch(val)
+	c, err := converter.New(field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create converter: %w", err)
+	}
+
+	return &fieldConformer{
+		field:     field,
+		re:        field.Regexp(),
+		converter: c,
+		tags:      "trim",
+	}, nil
+}
+
+// conformValue conforms a single value, adding it to the report when it fails.
+func (fc *fieldConformer) conformValue(ctx context.Context, path, val string, report *Report) any {
+	conformed, err := fc.conform(ctx, val)
+	if err != nil {
+		logger.Log.Debugw("failed to conform value", "field", path, "val", val, "error", err)
+		*report = append(*report, FieldError{Field: path, Value: val, Error: err.Error()})
+
+		return nil
+	}
+
+	return conformed
+}
+
+func (fc *fieldConformer) conform(ctx context.Context, val string) (any, error) {
+	if fc.re == nil {
+		return fc.convert(ctx, val)
+	}
+
+	if fc.field.RegexAll {
+		matches := fc.re.FindAllStringSubmatchIndex(val, -1)
 
 		logger.Log.Debugw(
-			"regex matches", "name", field.Name, "val", val, "regex", field.Regex, "matches", matches,
+			"regex matches", "name", fc.field.Name, "val", val, "regex", fc.field.Regex, "matches", len(matches),
 		)
 
-		val = matches[1]
+		if len(matches) == 0 {
+			return fc.noMatch(ctx, val)
+		}
+
+		values := make([]any, 0, len(matches))
+		for i := range matches {
+			v, err := fc.conformMatch(ctx, val, matches[i])
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, v)
+		}
+
+		return values, nil
+	}
+
+	match := fc.re.FindStringSubmatchIndex(val)
+
+	logger.Log.Debugw(
+		"regex matches", "name", fc.field.Name, "val", val, "regex", fc.field.Regex, "match", match,
+	)
+
+	if match == nil {
+		return fc.noMatch(ctx, val)
 	}
 
-	conform := modifiers.New()
+	return fc.conformMatch(ctx, val, match)
+}
 
-	tags := []string{"trim"}
-	switch field.Type {
-	case converter.TypeNumber, converter.TypeDecimal:
-		tags = append(tags, "strip_alpha", "strip_alpha_unicode", "strip_punctuation")
+// conformMatch conforms a single regex match, given as submatch indexes.
+func (fc *fieldConformer) conformMatch(ctx context.Context, val string, match []int) (any, error) {
+	if fc.field.Replace != "" {
+		return fc.convert(ctx, string(fc.re.ExpandString(nil, fc.field.Replace, val, match)))
 	}
 
-	tags = lo.Uniq(tags)
+	if hasNamedGroups(fc.re) {
+		object := make(map[string]any)
+		for i, name := range fc.re.SubexpNames() {
+			if name == "" {
+				continue
+			}
+
+			// An optional group that did not participate in the match has no value.
+			if match[2*i] < 0 {
+				object[name] = nil
 
-	if err = conform.Field(ctx, &val, strings.Join(tags, ",")); err != nil {
-		logger.Log.ErrorwContext(ctx, "failed to conform field", "error", err, "val", val, "tags", tags)
+				continue
+			}
 
-		return val
+			v, err := fc.convert(ctx, val[match[2*i]:match[2*i+1]])
+			if err != nil {
+				return nil, fmt.Errorf("failed to conform group %s: %w", name, err)
+			}
+
+			object[name] = v
+		}
+
+		return object, nil
 	}
 
-	c, err := converter.New(field.Type)
-	if err != nil {
-		logger.Log.ErrorwContext(ctx, "failed to create converter", "error", err, "field", field)
+	// Without any capture group, the whole match is extracted.
+	group := 0
+	if fc.re.NumSubexp() > 0 {
+		group = 1
+	}
+
+	if match[2*group] < 0 {
+		return fc.noMatch(ctx, val)
+	}
+
+	return fc.convert(ctx, val[match[2*group]:match[2*group+1]])
+}
 
-		return val
+// noMatch returns what a value the regex does not match becomes.
+func (fc *fieldConformer) noMatch(ctx context.Context, val string) (any, error) {
+	switch fc.field.NoMatch {
+	case NoMatchKeep:
+		return fc.convert(ctx, val)
+	case NoMatchNull:
+		return nil, nil //nolint:nilnil
+	default:
+		return nil, fmt.Errorf("regex %q did not match", fc.field.Regex)
 	}
+}
+
+// convert sanitizes a value then converts it to the type of the field.
+func (fc *fieldConformer) convert(ctx context.Context, val string) (any, error) {
+	if err := modifier.Field(ctx, &val, fc.tags); err != nil {
+		return nil, fmt.Errorf("failed to conform field: %w", err)
+	}
+
+	return fc.converter.Convert(ctx, val, fc.field)
+}
 
-	return c.Convert(ctx, val, field)
+func hasNamedGroups(re *regexp.Regexp) bool {
+	return lo.SomeBy(re.SubexpNames(), func(name string) bool {
+		return name != ""
+	})
 }
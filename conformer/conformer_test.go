@@ -0,0 +1,146 @@
+package conformer_test
+
+import (
+	"context"
+
+	"github.com/mgjules/harvit/conformer"
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+)
+
+var _ = Describe("Conformer", func() {
+	BeforeEach(func() {
+		_, err := logger.New(false)
+		Expect(err).To(BeNil())
+	})
+
+	fields := []plan.Field{
+		{Name: "title", Type: converter.TypeText},
+		{Name: "price", Type: converter.TypeNumber},
+		{Name: "posted", Type: converter.TypeDateTime},
+		{
+			Name: "jobs",
+			Type: converter.TypeList,
+			Fields: []plan.Field{
+				{Name: "salary", Type: converter.TypeDecimal},
+			},
+		},
+	}
+
+	It("should conform the values", func() {
+		conformed, report, err := conformer.Conform(context.Background(), fields, map[string]any{
+			"title":  " Gopher ",
+			"price":  "42",
+			"posted": "2022-02-02 10:00:00",
+			"jobs":   []map[string]any{{"salary": "1,337.50 EUR"}},
+		})
+		Expect(err).To(BeNil())
+		Expect(report).To(BeEmpty())
+
+		Expect(conformed).To(HaveKeyWithValue("title", "Gopher"))
+		Expect(conformed).To(HaveKeyWithValue("price", int64(42)))
+		Expect(conformed).To(HaveKey("posted"))
+		Expect(conformed["posted"]).To(HavePrefix("2022-02-02T10:00:00"))
+		Expect(conformed).To(HaveKeyWithValue("jobs", []any{map[string]any{"salary": 1337.5}}))
+	})
+
+	It("should report the values that failed to conform", func() {
+		conformed, report, err := conformer.Conform(context.Background(), fields, map[string]any{
+			"price":  "N/A",
+			"posted": "yesterday-ish",
+			"jobs":   []map[string]any{{"salary": "1000"}, {"salary": "TBD"}},
+		})
+		Expect(err).To(BeNil())
+
+		Expect(conformed).To(HaveKeyWithValue("price", BeNil()))
+		Expect(conformed).To(HaveKeyWithValue("posted", BeNil()))
+		Expect(conformed).To(HaveKeyWithValue("jobs", []any{
+			map[string]any{"salary": 1000.0},
+			map[string]any{"salary": nil},
+		}))
+
+		Expect(report).To(HaveLen(3))
+		Expect(report[0].Field).To(Equal("price"))
+		Expect(report[1].Field).To(Equal("posted"))
+		Expect(report[1].Value).To(Equal("yesterday-ish"))
+		Expect(report[2].Field).To(Equal("jobs[1].salary"))
+		Expect(report[2].Error).NotTo(BeEmpty())
+	})
+
+	It("should reject a field that is not compiled", func() {
+		_, _, err := conformer.Conform(context.Background(), []plan.Field{
+			{Name: "price", Type: converter.TypeNumber, Regex: `(\d+)`},
+		}, map[string]any{"price": "42 EUR"})
+		Expect(err).NotTo(BeNil())
+	})
+
+	DescribeTable("should extract values with a regex",
+		func(field plan.Field, val string, expected any, errors int) {
+			field.SetDefaults()
+			Expect(field.Compile()).To(Succeed())
+
+			conformed, report, err := conformer.Conform(
+				context.Background(), []plan.Field{field}, map[string]any{field.Name: val},
+			)
+			Expect(err).To(BeNil())
+			Expect(report).To(HaveLen(errors))
+			Expect(conformed).To(HaveKeyWithValue(field.Name, expected))
+		},
+		Entry("from the first group",
+			plan.Field{Name: "price", Type: converter.TypeNumber, Regex: `(\d+) EUR`},
+			"Price: 42 EUR", int64(42), 0,
+		),
+		Entry("from the whole match without any group",
+			plan.Field{Name: "price", Type: converter.TypeNumber, Regex: `\d+`},
+			"Price: 42 EUR", int64(42), 0,
+		),
+		Entry("reporting a value without match by default",
+			plan.Field{Name: "price", Type: converter.TypeNumber, Regex: `(\d+) EUR`},
+			"Free", BeNil(), 1,
+		),
+		Entry("as null without match",
+			plan.Field{Name: "price", Type: converter.TypeNumber, Regex: `(\d+) EUR`, NoMatch: conformer.NoMatchNull},
+			"Free", BeNil(), 0,
+		),
+		Entry("keeping the original value without match",
+			plan.Field{Name: "title", Type: converter.TypeText, Regex: `Senior (.+)`, NoMatch: conformer.NoMatchKeep},
+			"Gopher", "Gopher", 0,
+		),
+		Entry("as an object from named groups",
+			plan.Field{Name: "price", Type: converter.TypeText, Regex: `(?P<amount>\d+) (?P<currency>\w+)`},
+			"Price: 42 EUR", map[string]any{"amount": "42", "currency": "EUR"}, 0,
+		),
+		Entry("as an object with null optional groups",
+			plan.Field{Name: "salary", Type: converter.TypeNumber, Regex: `(?P<min>\d+)(?:\s*-\s*(?P<max>\d+))?`},
+			"40 per hour", map[string]any{"min": int64(40), "max": nil}, 0,
+		),
+		Entry("as a list of every match",
+			plan.Field{Name: "tags", Type: converter.TypeText, Regex: `#(\w+)`, RegexAll: true},
+			"#go #rust #zig", []any{"go", "rust", "zig"}, 0,
+		),
+		Entry("with a replacement template",
+			plan.Field{Name: "date", Type: converter.TypeText, Regex: `(\d+)/(\d+)`, Replace: "$2-$1"},
+			"on 02/2022", "2022-02", 0,
+		),
+	)
+
+	DescribeTable("should shape values to the declared cardinality",
+		func(multiple *bool, data map[string]any, expected any) {
+			field := plan.Field{Name: "tags", Type: converter.TypeText, Multiple: multiple}
+
+			conformed, _, err := conformer.Conform(context.Background(), []plan.Field{field}, data)
+			Expect(err).To(BeNil())
+			Expect(conformed).To(HaveKeyWithValue("tags", expected))
+		},
+		Entry("as a list from a single value", lo.ToPtr(true), map[string]any{"tags": "go"}, []any{"go"}),
+		Entry("as an empty list without values", lo.ToPtr(true), map[string]any{}, BeEmpty()),
+		Entry("as the first of several values", lo.ToPtr(false), map[string]any{"tags": []string{"go", "zig"}}, "go"),
+		Entry("as null without values", lo.ToPtr(false), map[string]any{}, BeNil()),
+		Entry("as null from an empty list", lo.ToPtr(false), map[string]any{"tags": []string{}}, BeNil()),
+		Entry("as harvested by default", nil, map[string]any{"tags": []string{"go"}}, []any{"go"}),
+	)
+})
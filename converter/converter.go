@@ -14,6 +14,8 @@ const (
 	TypeNumber   = "number"
 	TypeDecimal  = "decimal"
 	TypeDateTime = "datetime"
+	TypeObject   = "object"
+	TypeList     = "list"
 )
 
 // New returns a new Converter.
@@ -33,6 +35,7 @@ func New(typ string) (Converter, error) {
 }
 
 // Converter converts a string to another format using plan.Field.
+// It returns an error when the string cannot be converted.
 type Converter interface {
-	Convert(context.Context, string, *plan.Field) any
+	Convert(context.Context, string, *plan.Field) (any, error)
 }
@@ -2,6 +2,7 @@ package converter
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/golang-module/carbon/v2"
 	"github.com/mgjules/harvit/plan"
@@ -11,7 +12,7 @@ import (
 type DateTime struct{}
 
 // Convert converts a string to a date.
-func (DateTime) Convert(_ context.Context, s string, field *plan.Field) any {
+func (DateTime) Convert(_ context.Context, s string, field *plan.Field) (any, error) {
 	var parsed carbon.Carbon
 	if field.Format == "" {
 		parsed = carbon.Parse(s)
@@ -19,9 +20,19 @@ func (DateTime) Convert(_ context.Context, s string, field *plan.Field) any {
 		parsed = carbon.ParseByFormat(s, field.Format)
 	}
 
-	if field.Timezone != "" {
+	if loc := field.Location(); loc != nil {
+		parsed = parsed.SetLocation(loc)
+	} else if field.Timezone != "" {
 		parsed = parsed.SetTimezone(field.Timezone)
 	}
 
-	return parsed.ToIso8601String()
+	if parsed.Error != nil {
+		return nil, fmt.Errorf("failed to parse datetime %q: %w", s, parsed.Error)
+	}
+
+	if parsed.IsInvalid() {
+		return nil, fmt.Errorf("failed to parse datetime %q", s)
+	}
+
+	return parsed.ToIso8601String(), nil
 }
@@ -2,7 +2,9 @@ package converter
 
 import (
 	"context"
-	"strconv"
+	"errors"
+	"fmt"
+	"math"
 
 	"github.com/mgjules/harvit/plan"
 )
@@ -10,12 +12,18 @@ import (
 // Decimal is a converter that converts a string to a decimal.
 type Decimal struct{}
 
-// Convert converts a string to a decimal.
-func (Decimal) Convert(_ context.Context, s string, _ *plan.Field) any {
-	sanitized, err := strconv.ParseFloat(s, bitSize)
+// Convert converts the first number of a string to a decimal e.g "1.234,56 €" or "1.5e-3",
+// using the decimal and group separators of the field.
+func (Decimal) Convert(_ context.Context, s string, field *plan.Field) (any, error) {
+	r, err := parseNumber(s, field)
 	if err != nil {
-		sanitized = 0.0
+		return nil, fmt.Errorf("failed to parse decimal %q: %w", s, err)
 	}
 
-	return sanitized
+	f, _ := r.Float64()
+	if math.IsInf(f, 0) {
+		return nil, fmt.Errorf("failed to parse decimal %q: %w", s, errors.New("out of range"))
+	}
+
+	return f, nil
 }
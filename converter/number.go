@@ -2,25 +2,30 @@ package converter
 
 import (
 	"context"
-	"strconv"
+	"errors"
+	"fmt"
 
 	"github.com/mgjules/harvit/plan"
 )
 
-const (
-	base    = 10
-	bitSize = 64
-)
-
 // Number is a converter that converts a string to a number.
 type Number struct{}
 
-// Convert converts a string to a number.
-func (Number) Convert(_ context.Context, s string, _ *plan.Field) any {
-	sanitized, err := strconv.ParseInt(s, base, bitSize)
+// Convert converts the first number of a string to a number e.g "-1,234 €" or "1.2k" with magnitudes,
+// using the decimal and group separators of the field.
+func (Number) Convert(_ context.Context, s string, field *plan.Field) (any, error) {
+	r, err := parseNumber(s, field)
 	if err != nil {
-		sanitized = 0
+		return nil, fmt.Errorf("failed to parse number %q: %w", s, err)
+	}
+
+	if !r.IsInt() {
+		return nil, fmt.Errorf("failed to parse number %q: %w", s, errors.New("not an integer"))
+	}
+
+	if !r.Num().IsInt64() {
+		return nil, fmt.Errorf("failed to parse number %q: %w", s, errors.New("out of range"))
 	}
 
-	return sanitized
+	return r.Num().Int64(), nil
 }
@@ -0,0 +1,182 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mgjules/harvit/plan"
+)
+
+// maxExponent bounds the exponent of numbers in scientific notation, beyond what a decimal can hold.
+const maxExponent = 400
+
+// magnitudeSuffixes are the multipliers of the magnitude suffixes e.g "1.2k" or "3 bn".
+var magnitudeSuffixes = map[string]int64{
+	"k": 1e3, "K": 1e3, "thousand": 1e3,
+	"M": 1e6, "mn": 1e6, "Mio": 1e6, "million": 1e6,
+	"bn": 1e9, "Mrd": 1e9, "billion": 1e9,
+	"tn": 1e12, "trillion": 1e12,
+}
+
+// numberPatterns caches the patterns matching numbers by separators and magnitudes.
+var numberPatterns sync.Map
+
+// numberPatternKey identifies a number pattern in the cache.
+type numberPatternKey struct {
+	decimal, group rune
+	magnitudes     bool
+}
+
+// numberPattern returns the pattern matching the first number of a text, capturing its sign, integer part,
+// fractional part, exponent, what makes the number ambiguous and its magnitude suffix, if enabled.
+// A number is ambiguous when directly followed by a separator or a space and more digits e.g "1.2.3" or "1 000"
+// without a space group separator, or by an exponent marker without digits e.g "1e".
+// The fractional part may come first e.g ".5", when it does not follow a letter or digit e.g "No.5".
+// The sign must not follow a letter or digit e.g "COVID-19".
+func numberPattern(decimal, group rune, magnitudes bool) *regexp.Regexp {
+	key := numberPatternKey{decimal: decimal, group: group, magnitudes: magnitudes}
+	if re, found := numberPatterns.Load(key); found {
+		return re.(*regexp.Regexp) //nolint:forcetypeassert
+	}
+
+	dec, grp := separatorPattern(decimal), separatorPattern(group)
+	sign := `(?:^|[^\p{L}\p{N}])([-−+])[\p{Sc}\s]*`
+
+	pattern := fmt.Sprintf(
+		`(?:(?:%[1]s)?(\d+(?:%[3]s\d+)*)(?:%[2]s(\d+))?|(?:%[1]s|^|[^\p{L}\p{N}])%[2]s(\d+))`+
+			`(?:[eE]([-−+]?\d+))?((?:%[2]s|%[3]s|[\s\x{00A0}\x{202F}])\d|[eE][-−+]?(?:[^\p{L}\p{N}]|$))?`,
+		sign, dec, grp,
+	)
+
+	if magnitudes {
+		suffixes := make([]string, 0, len(magnitudeSuffixes))
+		for suffix := range magnitudeSuffixes {
+			suffixes = append(suffixes, regexp.QuoteMeta(suffix))
+		}
+
+		// Longest suffixes first, so that "Mio" is not read as "M".
+		sort.Slice(suffixes, func(i, j int) bool {
+			if len(suffixes[i]) != len(suffixes[j]) {
+				return len(suffixes[i]) > len(suffixes[j])
+			}
+
+			return suffixes[i] < suffixes[j]
+		})
+
+		pattern += fmt.Sprintf(`(?:[ \x{00A0}\x{202F}]?(%s)\b)?`, strings.Join(suffixes, "|"))
+	}
+
+	re := regexp.MustCompile(pattern)
+
+	numberPatterns.Store(key, re)
+
+	return re
+}
+
+// separatorPattern matches a separator, along with its variants for spaces and apostrophes.
+func separatorPattern(sep rune) string {
+	switch {
+	case unicode.IsSpace(sep) || sep == ' ':
+		return `[ \x{00A0}\x{202F}]`
+	case sep == '\'' || sep == '’':
+		return `['’]`
+	default:
+		return regexp.QuoteMeta(string(sep))
+	}
+}
+
+// parseNumber parses the first number of a text e.g "-1.234,56 €", "1.2e3", ".5" or "1.2k",
+// using the decimal and group separators of the field and its magnitudes, if enabled.
+// Ambiguous numbers e.g "1.2.3", "1 000" without a space group separator or "1e" are rejected.
+func parseNumber(s string, field *plan.Field) (*big.Rat, error) {
+	decimal, group := '.', ','
+	var magnitudes bool
+	if field != nil {
+		decimal, group = field.Separators()
+		magnitudes = field.Magnitudes
+	}
+
+	m := numberPattern(decimal, group, magnitudes).FindStringSubmatch(s)
+	if m == nil {
+		return nil, errors.New("no number found")
+	}
+
+	sign, integer, fraction, exponent, trailing := m[1], m[2], m[3], m[6], m[7]
+	if m[5] != "" {
+		// Without integer part e.g ".5".
+		sign, integer, fraction = m[4], "0", m[5]
+	}
+
+	if trailing != "" {
+		return nil, fmt.Errorf("ambiguous number %q", strings.TrimSpace(m[0]))
+	}
+
+	var suffix string
+	if magnitudes {
+		suffix = m[8]
+	}
+
+	groups := strings.FieldsFunc(integer, func(r rune) bool { return !unicode.IsDigit(r) })
+	if !validGrouping(groups) {
+		return nil, fmt.Errorf("invalid digit grouping %q", integer)
+	}
+
+	var b strings.Builder
+	if sign == "-" || sign == "−" {
+		b.WriteByte('-')
+	}
+
+	b.WriteString(strings.Join(groups, ""))
+
+	if fraction != "" {
+		b.WriteByte('.')
+		b.WriteString(fraction)
+	}
+
+	if exponent != "" {
+		exp, err := strconv.Atoi(strings.ReplaceAll(exponent, "−", "-"))
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return nil, fmt.Errorf("exponent %q out of range", exponent)
+		}
+
+		b.WriteString("e" + strconv.Itoa(exp))
+	}
+
+	r, ok := new(big.Rat).SetString(b.String())
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", m[0])
+	}
+
+	if suffix != "" {
+		r.Mul(r, new(big.Rat).SetInt64(magnitudeSuffixes[suffix]))
+	}
+
+	return r, nil
+}
+
+// validGrouping reports whether digit groups are well formed: at most 3 digits first,
+// then 2 or 3 digits e.g "1,23,456" and 3 digits last.
+func validGrouping(groups []string) bool {
+	if len(groups) == 1 {
+		return true
+	}
+
+	if len(groups[0]) > 3 || len(groups[len(groups)-1]) != 3 {
+		return false
+	}
+
+	for _, g := range groups[1 : len(groups)-1] {
+		if len(g) != 2 && len(g) != 3 {
+			return false
+		}
+	}
+
+	return true
+}
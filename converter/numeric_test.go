@@ -0,0 +1,87 @@
+package converter_test
+
+import (
+	"context"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Numeric", func() {
+	DescribeTable("should convert numbers",
+		func(field plan.Field, val string, expected any) {
+			c, err := converter.New(field.Type)
+			Expect(err).To(BeNil())
+
+			converted, err := c.Convert(context.Background(), val, &field)
+			Expect(err).To(BeNil())
+			Expect(converted).To(Equal(expected))
+		},
+		Entry("plain", plan.Field{Type: converter.TypeNumber}, "1337", int64(1337)),
+		Entry("within text", plan.Field{Type: converter.TypeNumber}, "This is some leet number: 1337", int64(1337)),
+		Entry("grouped", plan.Field{Type: converter.TypeNumber}, "1,234,567 views", int64(1234567)),
+		Entry("negative", plan.Field{Type: converter.TypeNumber}, "-5", int64(-5)),
+		Entry("negative with currency", plan.Field{Type: converter.TypeNumber}, "Balance: −€42", int64(-42)),
+		Entry("not negative after a word", plan.Field{Type: converter.TypeNumber}, "COVID-19", int64(19)),
+		Entry("with a magnitude", plan.Field{Type: converter.TypeNumber, Magnitudes: true}, "1.2k followers", int64(1200)),
+		Entry("with a spaced magnitude",
+			plan.Field{Type: converter.TypeNumber, Magnitudes: true}, "$3 bn", int64(3000000000),
+		),
+		Entry("not a magnitude", plan.Field{Type: converter.TypeNumber, Magnitudes: true}, "5 km", int64(5)),
+		Entry("not an exponent", plan.Field{Type: converter.TypeNumber}, "5em", int64(5)),
+		Entry("followed by a word and digits", plan.Field{Type: converter.TypeNumber}, "3 items in 2 boxes", int64(3)),
+		Entry("without magnitudes", plan.Field{Type: converter.TypeNumber}, "Size 5 M", int64(5)),
+		Entry("without magnitudes after a unit", plan.Field{Type: converter.TypeNumber}, "2 mn", int64(2)),
+		Entry("in scientific notation", plan.Field{Type: converter.TypeNumber}, "1.5e3", int64(1500)),
+		Entry("in a locale", plan.Field{Type: converter.TypeNumber, Locale: "de"}, "1.234 Einträge", int64(1234)),
+		Entry("decimal", plan.Field{Type: converter.TypeDecimal}, "13.37", 13.37),
+		Entry("decimal within text", plan.Field{Type: converter.TypeDecimal}, "Price: 1,234.56 USD", 1234.56),
+		Entry("decimal in a locale", plan.Field{Type: converter.TypeDecimal, Locale: "de-DE"}, "1.234,56 €", 1234.56),
+		Entry("decimal with spaced groups", plan.Field{Type: converter.TypeDecimal, Locale: "fr"}, "1 234,5 €", 1234.5),
+		Entry("decimal in a regional locale",
+			plan.Field{Type: converter.TypeDecimal, Locale: "de_CH"}, "CHF 1'234.50", 1234.5,
+		),
+		Entry("decimal with explicit separators",
+			plan.Field{Type: converter.TypeDecimal, DecimalSeparator: ","}, "1.234,56", 1234.56,
+		),
+		Entry("negative decimal", plan.Field{Type: converter.TypeDecimal}, "-0.5", -0.5),
+		Entry("decimal in scientific notation", plan.Field{Type: converter.TypeDecimal}, "6.02E23", 6.02e23),
+		Entry("decimal with a negative exponent", plan.Field{Type: converter.TypeDecimal}, "1.5e-3", 0.0015),
+		Entry("decimal with a magnitude", plan.Field{Type: converter.TypeDecimal, Magnitudes: true}, "€2.5M", 2500000.0),
+		Entry("decimal with a word magnitude",
+			plan.Field{Type: converter.TypeDecimal, Locale: "de", Magnitudes: true}, "1,5 Mio", 1500000.0,
+		),
+		Entry("decimal without integer part", plan.Field{Type: converter.TypeDecimal}, ".5", 0.5),
+		Entry("decimal without integer part after a currency", plan.Field{Type: converter.TypeDecimal}, "$.99", 0.99),
+		Entry("negative decimal without integer part", plan.Field{Type: converter.TypeDecimal}, "-.25", -0.25),
+		Entry("decimal without integer part in a locale",
+			plan.Field{Type: converter.TypeDecimal, Locale: "fr"}, "Remise ,5 %", 0.5,
+		),
+		Entry("not a decimal after a word", plan.Field{Type: converter.TypeNumber}, "No.5", int64(5)),
+	)
+
+	DescribeTable("should reject invalid numbers",
+		func(field plan.Field, val string) {
+			c, err := converter.New(field.Type)
+			Expect(err).To(BeNil())
+
+			_, err = c.Convert(context.Background(), val, &field)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("without any number", plan.Field{Type: converter.TypeNumber}, "N/A"),
+		Entry("with a fraction", plan.Field{Type: converter.TypeNumber}, "13.37"),
+		Entry("out of range", plan.Field{Type: converter.TypeNumber}, "99999999999999999999"),
+		Entry("with a decimal comma in another locale", plan.Field{Type: converter.TypeDecimal}, "1,5"),
+		Entry("with a decimal point in another locale", plan.Field{Type: converter.TypeDecimal, Locale: "de"}, "13.37"),
+		Entry("with a huge exponent", plan.Field{Type: converter.TypeDecimal}, "1e999999999"),
+		Entry("with separators of another locale", plan.Field{Type: converter.TypeDecimal, Locale: "de"}, "1,234.56"),
+		Entry("with the separators reversed", plan.Field{Type: converter.TypeDecimal}, "1.234,56"),
+		Entry("followed by another separator", plan.Field{Type: converter.TypeDecimal}, "v1.2.3"),
+		Entry("with spaced groups in another locale", plan.Field{Type: converter.TypeNumber}, "1 000 000"),
+		Entry("with non-breaking spaced groups in another locale", plan.Field{Type: converter.TypeNumber}, "1\u00a0000"),
+		Entry("with a dangling exponent", plan.Field{Type: converter.TypeDecimal}, "1e"),
+		Entry("with a dangling signed exponent", plan.Field{Type: converter.TypeDecimal}, "2.5E- USD"),
+	)
+})
@@ -10,6 +10,6 @@ import (
 type Text struct{}
 
 // Convert converts a string to a string.
-func (Text) Convert(_ context.Context, s string, _ *plan.Field) any {
-	return s
+func (Text) Convert(_ context.Context, s string, _ *plan.Field) (any, error) {
+	return s, nil
 }
@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/mgjules/harvit/json"
+)
+
+// Change types.
+const (
+	TypeAdded   = "added"
+	TypeRemoved = "removed"
+	TypeChanged = "changed"
+)
+
+// Change is a single difference between two results.
+type Change struct {
+	// Path of the value e.g "price", "job.title" or "jobs[]" for a list item.
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Compare returns the changes from before to after.
+// Objects are compared key by key while lists are compared as sets of items,
+// so that items moving around a page are not reported as changes.
+func Compare(before, after any) ([]Change, error) {
+	o, err := json.Normalize(before)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := json.Normalize(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0)
+
+	return compare("", o, n, changes), nil
+}
+
+func compare(path string, before, after any, changes []Change) []Change {
+	switch {
+	case before == nil && after == nil:
+		return changes
+	case before == nil:
+		return append(changes, Change{Path: path, Type: TypeAdded, After: after})
+	case after == nil:
+		return append(changes, Change{Path: path, Type: TypeRemoved, Before: before})
+	}
+
+	switch o := before.(type) {
+	case map[string]any:
+		if n, ok := after.(map[string]any); ok {
+			return compareMaps(path, o, n, changes)
+		}
+	case []any:
+		if n, ok := after.([]any); ok {
+			return compareLists(path, o, n, changes)
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		changes = append(changes, Change{Path: path, Type: TypeChanged, Before: before, After: after})
+	}
+
+	return changes
+}
+
+func compareMaps(path string, before, after map[string]any, changes []Change) []Change {
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		changes = compare(p, before[k], after[k], changes)
+	}
+
+	return changes
+}
+
+func compareLists(path string, before, after []any, changes []Change) []Change {
+	p := path + "[]"
+
+	remaining := make(map[string]int, len(before))
+	for i := range before {
+		remaining[key(before[i])]++
+	}
+
+	for i := range after {
+		k := key(after[i])
+		if remaining[k] > 0 {
+			remaining[k]--
+
+			continue
+		}
+
+		changes = append(changes, Change{Path: p, Type: TypeAdded, After: after[i]})
+	}
+
+	for i := range before {
+		k := key(before[i])
+		if remaining[k] > 0 {
+			remaining[k]--
+			changes = append(changes, Change{Path: p, Type: TypeRemoved, Before: before[i]})
+		}
+	}
+
+	return changes
+}
+
+// key returns a canonical representation of a normalized value.
+func key(v any) string {
+	// Marshaling maps sorts their keys, which makes the output canonical.
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(marshaled)
+}
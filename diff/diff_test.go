@@ -0,0 +1,61 @@
+package diff_test
+
+import (
+	"github.com/mgjules/harvit/diff"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+	before := map[string]any{
+		"title":   "Jobs",
+		"count":   3,
+		"removed": "gone",
+		"company": map[string]any{"name": "Harvit", "city": "Port Louis"},
+		"jobs": []map[string]any{
+			{"title": "Gopher", "salary": 100},
+			{"title": "Rustacean", "salary": 200},
+			{"title": "Pythonista", "salary": 300},
+		},
+	}
+
+	after := map[string]any{
+		"title":   "Jobs",
+		"count":   3,
+		"added":   "new",
+		"company": map[string]any{"name": "Harvit", "city": "Curepipe"},
+		"jobs": []map[string]any{
+			{"title": "Pythonista", "salary": 300},
+			{"title": "Gopher", "salary": 150},
+			{"title": "Rustacean", "salary": 200},
+		},
+	}
+
+	It("should report added, removed and changed values", func() {
+		changes, err := diff.Compare(before, after)
+		Expect(err).To(BeNil())
+
+		Expect(changes).To(Equal([]diff.Change{
+			{Path: "added", Type: diff.TypeAdded, After: "new"},
+			{Path: "company.city", Type: diff.TypeChanged, Before: "Port Louis", After: "Curepipe"},
+			{
+				Path:  "jobs[]",
+				Type:  diff.TypeAdded,
+				After: map[string]any{"title": "Gopher", "salary": float64(150)},
+			},
+			{
+				Path:   "jobs[]",
+				Type:   diff.TypeRemoved,
+				Before: map[string]any{"title": "Gopher", "salary": float64(100)},
+			},
+			{Path: "removed", Type: diff.TypeRemoved, Before: "gone"},
+		}))
+	})
+
+	It("should report nothing for identical results", func() {
+		changes, err := diff.Compare(before, before)
+		Expect(err).To(BeNil())
+
+		Expect(changes).To(BeEmpty())
+	})
+})
@@ -6,21 +6,27 @@ toolchain go1.22.0
 
 require (
 	dario.cat/mergo v1.0.0
+	github.com/PuerkitoBio/goquery v1.9.2
+	github.com/andybalholm/cascadia v1.3.2
 	github.com/chromedp/cdproto v0.0.0-20240501202034-ef67d660e9fd
 	github.com/chromedp/chromedp v0.9.5
 	github.com/dop251/goja v0.0.0-20240220182346-e401ed450204
 	github.com/go-playground/mold/v4 v4.5.0
 	github.com/go-playground/validator/v10 v10.20.0
 	github.com/golang-module/carbon/v2 v2.3.12
+	github.com/jmespath/go-jmespath v0.4.0
 	github.com/json-iterator/go v1.1.12
 	github.com/magefile/mage v1.15.0
 	github.com/onsi/ginkgo/v2 v2.9.5
 	github.com/onsi/gomega v1.27.7
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/samber/lo v1.39.0
 	github.com/uptrace/opentelemetry-go-extra/otelzap v0.2.4
 	github.com/urfave/cli/v2 v2.27.2
 	go.uber.org/zap v1.27.0
+	golang.org/x/net v0.24.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -55,9 +61,7 @@ require (
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/crypto v0.23.0 // indirect
 	golang.org/x/exp v0.0.0-20240416160154-fe59bbe5cc7f // indirect
-	golang.org/x/net v0.24.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
 	golang.org/x/tools v0.20.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
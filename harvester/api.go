@@ -0,0 +1,216 @@
+package harvester
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+)
+
+// maxExactInt is the largest integer a float64 holds exactly, 2^53.
+const maxExactInt = 1 << 53
+
+// API is a harvester that harvests data from a JSON API.
+// Field selectors are JMESPath expressions.
+type API struct{}
+
+// Harvest harvests data from a JSON API using a plan.
+func (API) Harvest(ctx context.Context, p *plan.Plan) (map[string]any, error) {
+	body, err := fetch(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// Numbers are decoded as written so that large integers e.g IDs are not rounded.
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode source: %w", err)
+	}
+
+	harvested, err := harvestJSONFields(p.Fields, searchableNumbers(doc))
+	if err != nil {
+		return nil, err
+	}
+
+	markMissing(p.Fields, harvested)
+
+	return harvested, nil
+}
+
+func harvestJSONFields(fields []plan.Field, doc any) (map[string]any, error) {
+	harvested := make(map[string]any)
+
+	for i := range fields {
+		field := fields[i]
+
+		res, err := searchFirst(&field, doc)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Log.Debugw("querying", "name", field.Name, "selectors", field.AllSelectors(), "result", res)
+
+		if list, ok := res.([]any); ok {
+			from, to := field.Pick(len(list))
+			res = list[from:to]
+		}
+
+		switch field.Type {
+		case converter.TypeObject:
+			if res == nil {
+				continue
+			}
+
+			record, err := harvestJSONFields(field.Fields, res)
+			if err != nil {
+				return nil, err
+			}
+
+			harvested[field.Name] = record
+		case converter.TypeList:
+			list, _ := res.([]any)
+			records := make([]map[string]any, 0, len(list))
+			for j := range list {
+				record, err := harvestJSONFields(field.Fields, list[j])
+				if err != nil {
+					return nil, err
+				}
+
+				records = append(records, record)
+			}
+
+			harvested[field.Name] = records
+		default:
+			if val, ok := harvestJSON(&field, res); ok {
+				harvested[field.Name] = val
+			}
+		}
+	}
+
+	return harvested, nil
+}
+
+// searchFirst returns the result of the first of the selectors of a field matching anything,
+// or the result of the last one.
+func searchFirst(field *plan.Field, doc any) (any, error) {
+	var res any
+
+	for _, selector := range field.AllSelectors() {
+		var err error
+
+		res, err = jmespath.Search(selector, doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search field %q: %w", field.Name, err)
+		}
+
+		if list, ok := res.([]any); res != nil && (!ok || len(list) > 0) {
+			if len(field.Selectors) > 0 {
+				logger.Log.Debugw("selector matched", "name", field.Name, "selector", selector)
+			}
+
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// searchableNumbers converts the numbers of a document decoded with UseNumber to float64,
+// which JMESPath needs to compare them and to pass them to functions.
+// Integers a float64 cannot hold exactly are kept as written.
+func searchableNumbers(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k := range t {
+			t[k] = searchableNumbers(t[k])
+		}
+	case []any:
+		for i := range t {
+			t[i] = searchableNumbers(t[i])
+		}
+	case json.Number:
+		if f, err := t.Float64(); err == nil && exactFloat(t) {
+			return f
+		}
+	}
+
+	return v
+}
+
+// exactFloat reports whether converting n to float64 keeps its value:
+// decimals are approximations anyway, while integers above 2^53 would be rounded.
+func exactFloat(n json.Number) bool {
+	if strings.ContainsAny(n.String(), ".eE") {
+		return true
+	}
+
+	i, err := n.Int64()
+
+	return err == nil && i >= -maxExactInt && i <= maxExactInt
+}
+
+// harvestJSON converts a JMESPath result into the string or []string shape
+// expected by the conformer. Raw fields are kept as JSON.
+func harvestJSON(field *plan.Field, res any) (any, bool) {
+	if res == nil {
+		return nil, false
+	}
+
+	list, ok := res.([]any)
+	if field.Type == converter.TypeRaw || !ok {
+		val, ok := stringifyJSON(res)
+		if ok && field.IsMultiple() {
+			return []string{val}, true
+		}
+
+		return val, ok
+	}
+
+	values := make([]string, 0, len(list))
+	for i := range list {
+		if list[i] == nil {
+			continue
+		}
+
+		if val, ok := stringifyJSON(list[i]); ok {
+			values = append(values, val)
+		}
+	}
+
+	if field.Multiple == nil && !field.First && !field.Last && field.Index == nil {
+		// Without a cardinality, an array is harvested as a list whatever its length.
+		return values, true
+	}
+
+	return shapeValues(field, values, len(values))
+}
+
+func stringifyJSON(v any) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case json.Number:
+		return t.String(), true
+	case bool:
+		return strconv.FormatBool(t), true
+	default:
+		marshaled, err := json.Marshal(t)
+		if err != nil {
+			return "", false
+		}
+
+		return string(marshaled), true
+	}
+}
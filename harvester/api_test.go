@@ -0,0 +1,219 @@
+package harvester_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	"github.com/samber/lo"
+)
+
+var _ = Describe("API", func() {
+	b, err := ioutil.ReadFile("testdata/api.json")
+	Expect(err).To(BeNil())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("X-Token") != "secret" || string(body) != `{"page":1}` {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, strings.TrimSpace(string(b)))
+	}))
+	AfterEach(ts.Close)
+
+	p := plan.Plan{
+		Source: ts.URL,
+		Type:   harvester.TypeAPI,
+		Request: plan.Request{
+			Method:  http.MethodPost,
+			Headers: map[string]string{"X-Token": "secret"},
+			Body:    `{"page":1}`,
+		},
+		Fields: []plan.Field{
+			{
+				Name:     "raw",
+				Type:     converter.TypeRaw,
+				Selector: "meta",
+			},
+			{
+				Name:     "text",
+				Type:     converter.TypeText,
+				Selector: "text",
+			},
+			{
+				Name:     "textList",
+				Type:     converter.TypeText,
+				Selector: "items[].name",
+			},
+			{
+				Name:     "number",
+				Type:     converter.TypeNumber,
+				Selector: "number",
+			},
+			{
+				Name:     "decimal",
+				Type:     converter.TypeDecimal,
+				Selector: "decimal",
+			},
+			{
+				Name:     "decimalList",
+				Type:     converter.TypeDecimal,
+				Selector: "items[].price",
+			},
+			{
+				Name:     "datetime",
+				Type:     converter.TypeDateTime,
+				Selector: "datetime",
+			},
+			{
+				Name:     "boolean",
+				Type:     converter.TypeText,
+				Selector: "active",
+			},
+			{
+				Name:     "items",
+				Type:     converter.TypeList,
+				Selector: "items",
+				Fields: []plan.Field{
+					{
+						Name:     "name",
+						Type:     converter.TypeText,
+						Selector: "name",
+					},
+					{
+						Name:     "price",
+						Type:     converter.TypeDecimal,
+						Selector: "price",
+					},
+				},
+			},
+			{
+				Name:     "missing",
+				Type:     converter.TypeText,
+				Selector: "missing",
+			},
+		},
+	}
+
+	expected := map[string]any{
+		"raw":  `{"page":1}`,
+		"text": "Some t3xt!",
+		"textList": []string{
+			"1Sw0C0tlYNfC2ookd5lr",
+			"ifpTMDlSfhMSCD",
+			"kRaQ5Lqtrbrk1oEq",
+		},
+		"number":             "1337",
+		"decimal":            "13.37",
+		"decimalList":        []string{"10", "20.5", "30"},
+		"datetime":           "08/06/2022 19:53:44",
+		"boolean":            "true",
+		harvester.MissingKey: []string{"missing"},
+		"items": []map[string]any{
+			{"name": "1Sw0C0tlYNfC2ookd5lr", "price": "10"},
+			{"name": "ifpTMDlSfhMSCD", "price": "20.5"},
+			{"name": "kRaQ5Lqtrbrk1oEq", "price": "30"},
+		},
+	}
+
+	_, err = logger.New(false)
+	Expect(err).To(BeNil())
+
+	h, err := harvester.New(p.Type)
+	Expect(err).To(BeNil())
+
+	It("should successfully harvest the data", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(expected))
+	})
+
+	DescribeTable("should harvest arrays to the declared cardinality",
+		func(field plan.Field, expected types.GomegaMatcher) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			path, err := filepath.Abs("testdata/api.json")
+			Expect(err).To(BeNil())
+
+			field.Type = converter.TypeText
+			data, err := h.Harvest(ctx, &plan.Plan{
+				Source: "file://" + path,
+				Type:   harvester.TypeAPI,
+				Fields: []plan.Field{field},
+			})
+			Expect(err).To(BeNil())
+			Expect(data).To(expected)
+		},
+		Entry("as a list by default",
+			plan.Field{Name: "names", Selector: "items[0:1].name"},
+			HaveKeyWithValue("names", []string{"1Sw0C0tlYNfC2ookd5lr"}),
+		),
+		Entry("as the first element of a single field",
+			plan.Field{Name: "names", Selector: "items[].name", Multiple: lo.ToPtr(false)},
+			HaveKeyWithValue("names", "1Sw0C0tlYNfC2ookd5lr"),
+		),
+		Entry("as the picked element",
+			plan.Field{Name: "names", Selector: "items[].name", Last: true},
+			HaveKeyWithValue("names", "kRaQ5Lqtrbrk1oEq"),
+		),
+		Entry("as missing from an empty array of a single field",
+			plan.Field{Name: "tags", Selector: "tags", Multiple: lo.ToPtr(false)},
+			HaveKeyWithValue(harvester.MissingKey, []string{"tags"}),
+		),
+	)
+
+	DescribeTable("should harvest numbers as written",
+		func(field plan.Field, expected types.GomegaMatcher) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			path, err := filepath.Abs("testdata/api.json")
+			Expect(err).To(BeNil())
+
+			data, err := h.Harvest(ctx, &plan.Plan{
+				Source: "file://" + path,
+				Type:   harvester.TypeAPI,
+				Fields: []plan.Field{field},
+			})
+			Expect(err).To(BeNil())
+			Expect(data).To(expected)
+		},
+		Entry("without rounding integers above 2^53",
+			plan.Field{Name: "id", Type: converter.TypeNumber, Selector: "id"},
+			HaveKeyWithValue("id", "9007199254740993"),
+		),
+		Entry("without rounding integers above 2^53 in raw fields",
+			plan.Field{Name: "ids", Type: converter.TypeRaw, Selector: "[id]"},
+			HaveKeyWithValue("ids", "[9007199254740993]"),
+		),
+		Entry("while comparing them in filters",
+			plan.Field{Name: "names", Type: converter.TypeText, Selector: "items[?price > `15`].name"},
+			HaveKeyWithValue("names", []string{"ifpTMDlSfhMSCD", "kRaQ5Lqtrbrk1oEq"}),
+		),
+		Entry("while passing them to functions",
+			plan.Field{Name: "total", Type: converter.TypeDecimal, Selector: "sum(items[].price)"},
+			HaveKeyWithValue("total", "60.5"),
+		),
+	)
+})
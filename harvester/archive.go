@@ -0,0 +1,272 @@
+package harvester
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpfetch "github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+)
+
+// Archive holds the responses recorded in a HAR file.
+// See: http://www.softwareishard.com/blog/har-12-spec
+type Archive struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []ArchiveEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// ArchiveEntry is a single request and its response.
+type ArchiveEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int             `json:"status"`
+		Headers []ArchiveHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding,omitempty"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// ArchiveHeader is a HTTP header of an archived response.
+type ArchiveHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// archives caches the archives loaded by path, as each page of a crawl reads the same archive.
+var archives sync.Map
+
+// LoadArchive loads a HAR file.
+func LoadArchive(path string) (*Archive, error) {
+	if a, found := archives.Load(path); found {
+		return a.(*Archive), nil //nolint:forcetypeassert
+	}
+
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var a Archive
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal archive: %w", err)
+	}
+
+	archives.Store(path, &a)
+
+	return &a, nil
+}
+
+// Save writes the archive to a HAR file.
+func (a *Archive) Save(path string) error {
+	marshaled, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Clean(path), marshaled, 0o600); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	archives.Delete(path)
+
+	return nil
+}
+
+// Lookup returns the archived response to a request, ignoring URL fragments.
+func (a *Archive) Lookup(method, rawURL string) (*ArchiveEntry, bool) {
+	target := stripFragment(rawURL)
+
+	for i := range a.Log.Entries {
+		entry := &a.Log.Entries[i]
+		if entry.Response.Status == 0 {
+			// The request failed when it was recorded.
+			continue
+		}
+
+		if strings.EqualFold(entry.Request.Method, method) && stripFragment(entry.Request.URL) == target {
+			return entry, true
+		}
+	}
+
+	return nil, false
+}
+
+// Page returns the URL of the first HTML document of the archive,
+// or of its first entry when it holds no HTML document.
+func (a *Archive) Page() (string, error) {
+	if len(a.Log.Entries) == 0 {
+		return "", errors.New("empty archive")
+	}
+
+	for i := range a.Log.Entries {
+		if strings.HasPrefix(a.Log.Entries[i].Response.Content.MimeType, "text/html") {
+			return a.Log.Entries[i].Request.URL, nil
+		}
+	}
+
+	return a.Log.Entries[0].Request.URL, nil
+}
+
+// Body returns the decoded body of the response.
+func (e *ArchiveEntry) Body() ([]byte, error) {
+	if e.Response.Content.Encoding == "base64" {
+		body, err := base64.StdEncoding.DecodeString(e.Response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode archived body: %w", err)
+		}
+
+		return body, nil
+	}
+
+	return []byte(e.Response.Content.Text), nil
+}
+
+// IsArchive reports whether a path is a HAR file.
+func IsArchive(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".har")
+}
+
+// replaySource returns the plan to harvest when its source is a HAR file:
+// the archive replays the responses and the first HTML document of the archive is the source.
+func replaySource(p *plan.Plan) (*plan.Plan, error) {
+	u, err := url.Parse(p.Source)
+	if err != nil || u.Scheme != "file" || !IsArchive(u.Path) {
+		return p, nil
+	}
+
+	a, err := LoadArchive(u.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := a.Page()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find source in archive: %w", err)
+	}
+
+	rp := *p
+	rp.Source = page
+	rp.Archive = u.Path
+
+	return &rp, nil
+}
+
+// fetchArchived returns the archived response body to the source of a plan.
+func fetchArchived(p *plan.Plan) (io.ReadCloser, error) {
+	a, err := LoadArchive(p.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	method := p.Request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	entry, found := a.Lookup(method, p.Source)
+	if !found {
+		return nil, fmt.Errorf("failed to fetch source: %s %s not found in archive", method, p.Source)
+	}
+
+	if entry.Response.Status < http.StatusOK || entry.Response.Status >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("failed to fetch source: unexpected status code %d", entry.Response.Status)
+	}
+
+	body, err := entry.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// replayArchive returns an action answering every request of the browser tab from an archive.
+// Requests missing from the archive fail as if the network was down.
+func replayArchive(a *Archive) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		chromedp.ListenTarget(ctx, func(ev any) {
+			paused, ok := ev.(*cdpfetch.EventRequestPaused)
+			if !ok {
+				return
+			}
+
+			go func() {
+				execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+
+				if err := replayRequest(execCtx, a, paused); err != nil {
+					logger.Log.WarnwContext(ctx, "failed to replay request", "url", paused.Request.URL, "error", err)
+				}
+			}()
+		})
+
+		return cdpfetch.Enable().Do(ctx)
+	})
+}
+
+func replayRequest(ctx context.Context, a *Archive, paused *cdpfetch.EventRequestPaused) error {
+	entry, found := a.Lookup(paused.Request.Method, paused.Request.URL)
+	if !found {
+		logger.Log.Debugw("request not found in archive", "method", paused.Request.Method, "url", paused.Request.URL)
+
+		return cdpfetch.FailRequest(paused.RequestID, network.ErrorReasonInternetDisconnected).Do(ctx)
+	}
+
+	body, err := entry.Body()
+	if err != nil {
+		return err
+	}
+
+	headers := make([]*cdpfetch.HeaderEntry, 0, len(entry.Response.Headers))
+	for _, h := range entry.Response.Headers {
+		// The archived body is already decoded.
+		switch strings.ToLower(h.Name) {
+		case "content-encoding", "content-length", "transfer-encoding":
+			continue
+		}
+
+		headers = append(headers, &cdpfetch.HeaderEntry{Name: h.Name, Value: h.Value})
+	}
+
+	return cdpfetch.FulfillRequest(paused.RequestID, int64(entry.Response.Status)).
+		WithResponseHeaders(headers).
+		WithBody(base64.StdEncoding.EncodeToString(body)).
+		Do(ctx)
+}
+
+func stripFragment(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	u.Fragment = ""
+
+	return u.String()
+}
@@ -0,0 +1,39 @@
+package harvester
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mgjules/harvit/logger"
+)
+
+// NewBrowser starts a browser shared by the website harvests run with the returned context.
+// Each harvest then opens a tab instead of starting its own browser.
+func NewBrowser(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := chromedp.NewContext(ctx)
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+
+		return nil, nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	return ctx, cancel, nil
+}
+
+// shareBrowser returns a context holding a browser for the harvests of a crawl.
+// A browser already held by ctx is reused; otherwise one is started, falling back to ctx on failure.
+func shareBrowser(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c := chromedp.FromContext(ctx); c != nil && c.Browser != nil {
+		return ctx, func() {}
+	}
+
+	browserCtx, cancel, err := NewBrowser(ctx)
+	if err != nil {
+		logger.Log.WarnwContext(ctx, "failed to start shared browser, each page will start its own", "error", err)
+
+		return ctx, func() {}
+	}
+
+	return browserCtx, cancel
+}
@@ -0,0 +1,322 @@
+package harvester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+)
+
+// Names of the internal fields used to harvest links.
+// They are not valid field names in a plan so they cannot collide.
+const (
+	nextField   = "_next"
+	followField = "_follow"
+)
+
+// Crawler is a harvester that follows the pagination and links of a plan,
+// harvesting each page with another harvester.
+type Crawler struct {
+	harvester Harvester
+}
+
+// NewCrawler returns a new Crawler harvesting each page with h.
+func NewCrawler(h Harvester) *Crawler {
+	return &Crawler{harvester: h}
+}
+
+// Harvest harvests every page of a plan and merges the results.
+// Only a failure on the source page is fatal; later pages end the crawl.
+// A HAR file source is replayed from its first HTML document.
+// Website pages and links share one browser.
+// Pages and links are only crawled when their scheme matches the source, so that a remote page
+// cannot lead to local files.
+func (c Crawler) Harvest(ctx context.Context, p *plan.Plan) (map[string]any, error) {
+	p, err := replaySource(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Pagination == nil && p.Follow == nil {
+		return c.harvester.Harvest(ctx, p)
+	}
+
+	if _, ok := c.harvester.(*Website); ok {
+		// Every page and followed link opens a tab in the same browser.
+		var cancel context.CancelFunc
+		ctx, cancel = shareBrowser(ctx)
+		defer cancel()
+	}
+
+	maxPages := 1
+	if p.Pagination != nil {
+		maxPages = p.Pagination.MaxPages
+	}
+
+	harvested := make(map[string]any)
+	if p.Follow != nil {
+		harvested[p.Follow.Name] = make([]map[string]any, 0)
+	}
+
+	visited := make(map[string]bool)
+	pageURL := p.Source
+	for page := 0; page < maxPages && pageURL != "" && !visited[pageURL]; page++ {
+		visited[pageURL] = true
+
+		logger.Log.Debugw("crawling page", "page", page+1, "url", pageURL)
+
+		data, err := c.harvester.Harvest(ctx, pagePlan(p, pageURL))
+		if err != nil {
+			if page == 0 {
+				return nil, fmt.Errorf("failed to harvest page %q: %w", pageURL, err)
+			}
+
+			// Running past the last page is expected with URL templates.
+			logger.Log.WarnwContext(ctx, "failed to harvest page, stopping", "url", pageURL, "error", err)
+
+			break
+		}
+
+		nextLinks := resolveLinks(pageURL, data[nextField])
+		followLinks := resolveLinks(pageURL, data[followField])
+		delete(data, nextField)
+		delete(data, followField)
+
+		// Fields missing from a page are listed once the crawl is done, from the merged data.
+		Missing(data)
+
+		if p.Follow != nil {
+			if p.Follow.MaxLinks > 0 && len(followLinks) > p.Follow.MaxLinks {
+				followLinks = followLinks[:p.Follow.MaxLinks]
+			}
+
+			harvested[p.Follow.Name] = append( //nolint:forcetypeassert
+				harvested[p.Follow.Name].([]map[string]any),
+				c.follow(ctx, p, followLinks)...,
+			)
+		}
+
+		mergeHarvested(harvested, data)
+
+		switch {
+		case p.Pagination == nil:
+			pageURL = ""
+		case p.Pagination.URL != "":
+			if emptyPage(p.Fields, data) {
+				// Running past the last page of a URL template.
+				pageURL = ""
+			} else {
+				pageURL = strings.ReplaceAll(p.Pagination.URL, "{page}", strconv.Itoa(p.Pagination.FirstPage()+page))
+			}
+
+			if pageURL != "" && !sameScheme(p.Source, pageURL) {
+				logger.Log.WarnwContext(ctx, "skipping page with a different scheme than the source", "url", pageURL)
+
+				pageURL = ""
+			}
+		case len(nextLinks) > 0:
+			pageURL = nextLinks[0]
+		default:
+			pageURL = ""
+		}
+	}
+
+	markMissing(p.Fields, harvested)
+
+	return harvested, nil
+}
+
+// emptyPage reports whether a page harvested nothing for every field of a plan.
+func emptyPage(fields []plan.Field, data map[string]any) bool {
+	for i := range fields {
+		switch v := data[fields[i].Name].(type) {
+		case nil:
+		case []string:
+			if len(v) > 0 {
+				return false
+			}
+		case []map[string]any:
+			if len(v) > 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// follow harvests the targets of the given links with the follow fields.
+// Targets that fail to harvest are logged and skipped.
+func (c Crawler) follow(ctx context.Context, p *plan.Plan, links []string) []map[string]any {
+	records := make([]map[string]any, 0, len(links))
+	for _, link := range links {
+		logger.Log.Debugw("following link", "url", link)
+
+		record, err := c.harvester.Harvest(ctx, &plan.Plan{
+			Source:     link,
+			Type:       p.Type,
+			UserAgents: p.UserAgents,
+			Timeout:    p.Timeout,
+			Archive:    p.Archive,
+			Request: plan.Request{
+				Method:  http.MethodGet,
+				Headers: p.Request.Headers,
+			},
+			Fields: p.Follow.Fields,
+		})
+		if err != nil {
+			logger.Log.ErrorwContext(ctx, "failed to harvest followed link", "url", link, "error", err)
+
+			continue
+		}
+
+		if missing := Missing(record); len(missing) > 0 {
+			logger.Log.WarnwContext(ctx, "missing fields in followed link", "url", link, "missing", missing)
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// pagePlan returns the plan used to harvest a single page,
+// with the internal link fields added.
+func pagePlan(p *plan.Plan, pageURL string) *plan.Plan {
+	pp := *p
+	pp.Source = pageURL
+	pp.Pagination = nil
+	pp.Follow = nil
+
+	pp.Fields = make([]plan.Field, 0, len(p.Fields)+2)
+	pp.Fields = append(pp.Fields, p.Fields...)
+
+	if p.Pagination != nil && p.Pagination.Next != "" {
+		pp.Fields = append(pp.Fields, linkField(nextField, p.Pagination.Next))
+	}
+
+	if p.Follow != nil {
+		pp.Fields = append(pp.Fields, linkField(followField, p.Follow.Selector))
+	}
+
+	return &pp
+}
+
+func linkField(name, selector string) plan.Field {
+	return plan.Field{
+		Name:      name,
+		Type:      converter.TypeText,
+		Selector:  selector,
+		Attribute: "href",
+		Extract:   ExtractFirst,
+		Optional:  true,
+	}
+}
+
+// resolveLinks resolves harvested links against the URL of the page they were found on.
+// Links with a different scheme than the page are dropped e.g a file:// link on a remote page.
+func resolveLinks(pageURL string, v any) []string {
+	var raw []string
+	switch t := v.(type) {
+	case string:
+		raw = []string{t}
+	case []string:
+		raw = t
+	default:
+		return nil
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	links := make([]string, 0, len(raw))
+	for i := range raw {
+		ref, err := url.Parse(strings.TrimSpace(raw[i]))
+		if err != nil {
+			continue
+		}
+
+		link := base.ResolveReference(ref)
+		if schemeFamily(link.Scheme) != schemeFamily(base.Scheme) {
+			continue
+		}
+
+		links = append(links, link.String())
+	}
+
+	return links
+}
+
+// sameScheme reports whether two URLs have the same scheme, http and https being the same.
+func sameScheme(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+
+	return schemeFamily(ua.Scheme) == schemeFamily(ub.Scheme)
+}
+
+func schemeFamily(scheme string) string {
+	scheme = strings.ToLower(scheme)
+	if scheme == "https" {
+		return "http"
+	}
+
+	return scheme
+}
+
+// mergeHarvested merges the data harvested from a page into dst.
+// Values of a field found on several pages are concatenated into a list.
+func mergeHarvested(dst, src map[string]any) {
+	for name, val := range src {
+		existing, found := dst[name]
+		if !found {
+			dst[name] = val
+
+			continue
+		}
+
+		dst[name] = mergeValues(existing, val)
+	}
+}
+
+func mergeValues(a, b any) any {
+	switch av := a.(type) {
+	case string:
+		return mergeValues([]string{av}, b)
+	case []string:
+		switch bv := b.(type) {
+		case string:
+			return append(av, bv)
+		case []string:
+			return append(av, bv...)
+		}
+	case map[string]any:
+		return mergeValues([]map[string]any{av}, b)
+	case []map[string]any:
+		switch bv := b.(type) {
+		case map[string]any:
+			return append(av, bv)
+		case []map[string]any:
+			return append(av, bv...)
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,287 @@
+package harvester_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+)
+
+var _ = Describe("Crawler", Ordered, func() {
+	pages := map[string]string{
+		"/jobs": `<ul>
+			<li><a href="/jobs/1">Gopher</a></li>
+			<li><a href="/jobs/2">Rustacean</a></li>
+		</ul>
+		<a class="next" href="/jobs?page=2">Next</a>`,
+		"/jobs?page=2": `<ul>
+			<li><a href="/jobs/3">Pythonista</a></li>
+		</ul>
+		<a class="next" href="/jobs">First</a>`,
+		"/jobs?page=0": `<ul>
+			<li><a href="/jobs/0">Archived</a></li>
+		</ul>`,
+		"/jobs/1": `<h1>Gopher</h1><p class="salary">100</p>`,
+		"/jobs/2": `<h1>Rustacean</h1><p class="salary">200</p>`,
+		"/jobs/3": `<h1>Pythonista</h1><p class="salary">300</p>`,
+	}
+
+	var boardRequests int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		page, found := pages[r.URL.RequestURI()]
+		if !found && r.URL.Path == "/board" {
+			// Past its last page, the board answers with empty pages.
+			boardRequests++
+			page, found = "<ul></ul>", true
+		}
+
+		if !found {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<!DOCTYPE html><html><body>%s</body></html>", page)
+	})
+
+	ts := httptest.NewServer(mux)
+	AfterAll(ts.Close)
+
+	_, err := logger.New(false)
+	Expect(err).To(BeNil())
+
+	h, err := harvester.New(harvester.TypeHTML)
+	Expect(err).To(BeNil())
+
+	fields := []plan.Field{
+		{
+			Name:     "titles",
+			Type:     converter.TypeText,
+			Selector: "ul > li > a",
+		},
+	}
+
+	It("should follow the next links and the job links", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := plan.Plan{
+			Source: ts.URL + "/jobs",
+			Type:   harvester.TypeHTML,
+			Fields: fields,
+			Pagination: &plan.Pagination{
+				Next:     "a.next",
+				MaxPages: 5,
+			},
+			Follow: &plan.Follow{
+				Name:     "jobs",
+				Selector: "ul > li > a",
+				Fields: []plan.Field{
+					{
+						Name:     "title",
+						Type:     converter.TypeText,
+						Selector: "h1",
+					},
+					{
+						Name:     "salary",
+						Type:     converter.TypeNumber,
+						Selector: "p.salary",
+					},
+				},
+			},
+		}
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles": []string{"Gopher", "Rustacean", "Pythonista"},
+			"jobs": []map[string]any{
+				{"title": "Gopher", "salary": "100"},
+				{"title": "Rustacean", "salary": "200"},
+				{"title": "Pythonista", "salary": "300"},
+			},
+		}))
+	})
+
+	It("should harvest the pages of a URL template", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := plan.Plan{
+			Source: ts.URL + "/jobs",
+			Type:   harvester.TypeHTML,
+			Fields: fields,
+			Pagination: &plan.Pagination{
+				URL:      ts.URL + "/jobs?page={page}",
+				MaxPages: 3,
+			},
+		}
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles": []string{"Gopher", "Rustacean", "Pythonista"},
+		}))
+	})
+
+	It("should start a URL template at page 0", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := plan.Plan{
+			Source: ts.URL + "/jobs",
+			Type:   harvester.TypeHTML,
+			Fields: fields,
+			Pagination: &plan.Pagination{
+				URL:      ts.URL + "/jobs?page={page}",
+				Start:    lo.ToPtr(0),
+				MaxPages: 2,
+			},
+		}
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles": []string{"Gopher", "Rustacean", "Archived"},
+		}))
+	})
+
+	It("should stop at the first empty page of a URL template", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := plan.Plan{
+			Source: ts.URL + "/jobs",
+			Type:   harvester.TypeHTML,
+			Fields: append([]plan.Field{{Name: "salary", Type: converter.TypeNumber, Selector: "p.salary"}}, fields...),
+			Pagination: &plan.Pagination{
+				URL:      ts.URL + "/board?page={page}",
+				Start:    lo.ToPtr(1),
+				MaxPages: 8,
+			},
+		}
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(boardRequests).To(Equal(1))
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles":             []string{"Gopher", "Rustacean"},
+			harvester.MissingKey: []string{"salary"},
+		}))
+	})
+
+	It("should not crawl local files from a remote page", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		secret := filepath.Join(GinkgoT().TempDir(), "secret.html")
+		Expect(os.WriteFile(secret, []byte(`<ul><li><a href="#">Secret</a></li></ul><h1>Secret</h1>`), 0o600)).To(Succeed())
+
+		pages["/leak"] = fmt.Sprintf(`<ul>
+			<li><a href="file://%[1]s">Gopher</a></li>
+		</ul>
+		<a class="next" href="file://%[1]s">Next</a>`, secret)
+
+		p := plan.Plan{
+			Source: ts.URL + "/leak",
+			Type:   harvester.TypeHTML,
+			Fields: fields,
+			Pagination: &plan.Pagination{
+				Next:     "a.next",
+				MaxPages: 5,
+			},
+			Follow: &plan.Follow{
+				Name:     "jobs",
+				Selector: "ul > li > a",
+				Fields: []plan.Field{
+					{
+						Name:     "title",
+						Type:     converter.TypeText,
+						Selector: "h1",
+					},
+				},
+			},
+		}
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles": "Gopher",
+			"jobs":   []map[string]any{},
+		}))
+
+		p.Follow = nil
+		p.Pagination = &plan.Pagination{
+			URL:      "file://" + secret + "?page={page}",
+			Start:    lo.ToPtr(2),
+			MaxPages: 3,
+		}
+
+		data, err = h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles": "Gopher",
+		}))
+	})
+
+	It("should crawl a website in a single browser", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		website, err := harvester.New(harvester.TypeWebsite)
+		Expect(err).To(BeNil())
+
+		p := plan.Plan{
+			Source: ts.URL + "/jobs",
+			Type:   harvester.TypeWebsite,
+			Fields: fields,
+			Pagination: &plan.Pagination{
+				Next:     "a.next",
+				MaxPages: 5,
+			},
+			Follow: &plan.Follow{
+				Name:     "jobs",
+				Selector: "ul > li > a",
+				Fields: []plan.Field{
+					{
+						Name:     "title",
+						Type:     converter.TypeText,
+						Selector: "h1",
+					},
+				},
+			},
+		}
+
+		data, err := website.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"titles": []string{"Gopher", "Rustacean", "Pythonista"},
+			"jobs": []map[string]any{
+				{"title": "Gopher"},
+				{"title": "Rustacean"},
+				{"title": "Pythonista"},
+			},
+		}))
+	})
+})
@@ -0,0 +1,256 @@
+package harvester
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+)
+
+// Extraction modes of the values of a field, in order of precedence.
+const (
+	modeAttribute = "attribute"
+	modeProperty  = "property"
+	modeRaw       = "raw"
+	modeFull      = "full"
+	modeFirst     = "first"
+	modeChildren  = "children"
+)
+
+// extractScript extracts the values of a set of fields from the page in a single evaluation.
+// Top-level selectors are CSS selectors or XPath expressions, child selectors are CSS selectors
+// evaluated relative to each node matched by their parent.
+// Like the DevTools DOM, the leading text node of a node ignores whitespace-only text nodes.
+// Only the picked nodes are extracted, see plan.Field.Pick.
+const extractScript = `(fields) => {
+	const isXPath = (selector) => /^(\/|\.\/|\()/.test(selector);
+
+	const query = (root, selector, search) => {
+		if (!search || !isXPath(selector)) {
+			return Array.from(root.querySelectorAll(selector));
+		}
+
+		const res = document.evaluate(selector, document, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null);
+		const nodes = [];
+		for (let i = 0; i < res.snapshotLength; i++) {
+			nodes.push(res.snapshotItem(i));
+		}
+
+		return nodes;
+	};
+
+	const pick = (field, nodes) => {
+		const n = nodes.length;
+		const bound = (i) => Math.min(Math.max(i < 0 ? i + n : i, 0), n);
+
+		const from = field.from == null ? 0 : bound(field.from);
+		let to = field.to == null ? n : bound(field.to);
+		if (from >= to) {
+			return [];
+		}
+
+		if (field.limit > 0 && to - from > field.limit) {
+			to = from + field.limit;
+		}
+
+		return nodes.slice(from, field.single ? from + 1 : to);
+	};
+
+	const value = (field, node) => {
+		switch (field.mode) {
+		case "attribute":
+			return node.getAttribute ? node.getAttribute(field.attribute) : null;
+		case "property": {
+			const v = node[field.property];
+
+			return v == null ? "" : String(v);
+		}
+		case "raw":
+			return node.outerHTML ?? node.textContent;
+		case "full":
+			return node.textContent;
+		}
+
+		const first = Array.from(node.childNodes)
+			.find((child) => child.nodeType !== Node.TEXT_NODE || child.nodeValue.trim() !== "");
+
+		return first && first.nodeType === Node.TEXT_NODE ? first.nodeValue : null;
+	};
+
+	const extract = (fields, root, search) => {
+		const extracted = {};
+		for (const field of fields) {
+			let nodes = [];
+			let selector = -1;
+			for (let i = 0; i < field.selectors.length; i++) {
+				try {
+					nodes = query(root, field.selectors[i], search);
+				} catch (err) {
+					throw new Error("field " + field.name + ": " + err.message);
+				}
+
+				if (nodes.length > 0) {
+					selector = i;
+					break;
+				}
+			}
+
+			nodes = pick(field, nodes);
+			extracted[field.name] = field.mode === "children"
+				? { selector, records: nodes.map((node) => extract(field.fields, node, false)) }
+				: { selector, values: nodes.map((node) => value(field, node)) };
+		}
+
+		return extracted;
+	};
+
+	return extract(fields, document, true);
+}`
+
+// extractSpec is a field as passed to the extraction script.
+type extractSpec struct {
+	Name      string   `json:"name"`
+	Selectors []string `json:"selectors"`
+	Mode      string   `json:"mode"`
+	Attribute string   `json:"attribute,omitempty"`
+	Property  string   `json:"property,omitempty"`
+	// Bounds and limit of the nodes to extract, see plan.Field.Pick.
+	From  *int `json:"from,omitempty"`
+	To    *int `json:"to,omitempty"`
+	Limit int  `json:"limit,omitempty"`
+	// Whether only the first picked node is needed.
+	Single bool          `json:"single,omitempty"`
+	Fields []extractSpec `json:"fields,omitempty"`
+}
+
+// extraction holds what the extraction script found for a field.
+type extraction struct {
+	// Index of the selector that matched, -1 when none did.
+	Selector int `json:"selector"`
+	// Values of the picked nodes, null for the nodes without any.
+	Values []*string `json:"values"`
+	// Records of the child fields for each picked node of object and list fields.
+	Records []map[string]extraction `json:"records"`
+}
+
+// extractExpression returns the expression evaluating the extraction script for a set of fields.
+func extractExpression(fields []plan.Field) (string, error) {
+	specs, err := json.Marshal(extractSpecs(fields))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal fields: %w", err)
+	}
+
+	return fmt.Sprintf("(%s)(%s)", extractScript, specs), nil
+}
+
+func extractSpecs(fields []plan.Field) []extractSpec {
+	specs := make([]extractSpec, 0, len(fields))
+	for i := range fields {
+		field := &fields[i]
+		from, to := field.Bounds()
+
+		specs = append(specs, extractSpec{
+			Name:      field.Name,
+			Selectors: field.AllSelectors(),
+			Mode:      extractMode(field),
+			Attribute: field.Attribute,
+			Property:  field.Property,
+			From:      from,
+			To:        to,
+			Limit:     field.Limit,
+			Single:    field.IsSingle() || field.Type == converter.TypeObject,
+			Fields:    extractSpecs(field.Fields),
+		})
+	}
+
+	return specs
+}
+
+func extractMode(field *plan.Field) string {
+	switch {
+	case field.Type == converter.TypeObject || field.Type == converter.TypeList:
+		return modeChildren
+	case field.Attribute != "":
+		return modeAttribute
+	case field.Property != "":
+		return modeProperty
+	case field.Type == converter.TypeRaw:
+		return modeRaw
+	case field.Extract == ExtractFull:
+		return modeFull
+	default:
+		return modeFirst
+	}
+}
+
+// harvestExtraction harvests what the extraction script found for a field.
+func harvestExtraction(ctx context.Context, field *plan.Field, ex extraction, harvested map[string]any) {
+	if len(field.Selectors) > 0 && ex.Selector >= 0 {
+		field = field.WithSelector(field.Selectors[ex.Selector])
+
+		logger.Log.Debugw("selector matched", "name", field.Name, "selector", field.Selector)
+	}
+
+	switch field.Type {
+	case converter.TypeObject:
+		logger.Log.Debugw("querying", "name", field.Name, "selector", field.Selector, "nodes", len(ex.Records))
+
+		if len(ex.Records) > 0 {
+			harvested[field.Name] = harvestRecord(ctx, field.Fields, ex.Records[0])
+		}
+	case converter.TypeList:
+		logger.Log.Debugw("querying", "name", field.Name, "selector", field.Selector, "nodes", len(ex.Records))
+
+		records := make([]map[string]any, 0, len(ex.Records))
+		for i := range ex.Records {
+			records = append(records, harvestRecord(ctx, field.Fields, ex.Records[i]))
+		}
+
+		harvested[field.Name] = records
+	default:
+		logger.Log.Debugw("querying", "name", field.Name, "selector", field.Selector, "nodes", len(ex.Values))
+
+		values := make([]string, 0, len(ex.Values))
+		for i := range ex.Values {
+			if val, ok := extractedValue(ctx, field, ex.Values[i]); ok {
+				values = append(values, val)
+			}
+		}
+
+		if val, ok := shapeValues(field, values, len(ex.Values)); ok {
+			harvested[field.Name] = val
+		}
+	}
+}
+
+func harvestRecord(ctx context.Context, fields []plan.Field, record map[string]extraction) map[string]any {
+	harvested := make(map[string]any)
+	for i := range fields {
+		harvestExtraction(ctx, &fields[i], record[fields[i].Name], harvested)
+	}
+
+	return harvested
+}
+
+func extractedValue(ctx context.Context, field *plan.Field, val *string) (string, bool) {
+	mode := extractMode(field)
+
+	switch {
+	case val == nil && mode == modeFirst:
+		logger.Log.WarnwContext(ctx,
+			"skipping node without a leading text node",
+			"name", field.Name, "selector", field.Selector,
+		)
+
+		return "", false
+	case val == nil:
+		return "", false
+	case mode == modeFull:
+		return normalizeSpace(*val), true
+	default:
+		return *val, true
+	}
+}
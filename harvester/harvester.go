@@ -3,26 +3,109 @@ package harvester
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mgjules/harvit/plan"
+	"github.com/samber/lo"
 )
 
 // Harvester types.
 const (
 	TypeWebsite = "website"
+	TypeHTML    = "html"
+	TypeAPI     = "api"
 )
 
+// Text extraction modes.
+const (
+	ExtractFirst = "first"
+	ExtractFull  = "full"
+)
+
+// Default timeouts used when a plan does not set them.
+const (
+	DefaultTimeout      = 30 * time.Second
+	DefaultFieldTimeout = 10 * time.Second
+)
+
+// MissingKey is the key under which harvesters list the fields that harvested nothing.
+const MissingKey = "_missing"
+
 // New returns a new Harvester.
+// The returned harvester crawls the pagination and links of a plan, if any.
 func New(typ string) (Harvester, error) {
+	var h Harvester
 	switch typ {
 	case TypeWebsite:
-		return &Website{}, nil
+		h = &Website{}
+	case TypeHTML:
+		h = &HTML{}
+	case TypeAPI:
+		h = &API{}
 	default:
 		return nil, fmt.Errorf("unknown harvester type: %s", typ)
 	}
+
+	return NewCrawler(h), nil
 }
 
 // Harvester harvests data using a given plan.
 type Harvester interface {
 	Harvest(context.Context, *plan.Plan) (map[string]any, error)
 }
+
+// normalizeSpace trims s and collapses any run of whitespace into a single space.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// shapeValues returns the values harvested for a field from its matched nodes in the shape it declares:
+// a list when multiple, the first value when single and by default a list only when several nodes matched.
+// It reports false when there is no value to harvest.
+func shapeValues(field *plan.Field, values []string, nodes int) (any, bool) {
+	switch {
+	case field.IsMultiple():
+		return values, len(values) > 0
+	case field.IsSingle() || nodes == 1:
+		if len(values) == 0 {
+			return nil, false
+		}
+
+		return values[0], true
+	case nodes > 1:
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+// markMissing lists the fields that harvested nothing under MissingKey.
+func markMissing(fields []plan.Field, harvested map[string]any) {
+	var missing []string
+	for i := range fields {
+		if _, found := harvested[fields[i].Name]; !found {
+			missing = append(missing, fields[i].Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		harvested[MissingKey] = missing
+	}
+}
+
+// Missing removes and returns the fields listed under MissingKey in harvested data.
+func Missing(harvested map[string]any) []string {
+	missing, _ := harvested[MissingKey].([]string)
+	delete(harvested, MissingKey)
+
+	return lo.Uniq(missing)
+}
+
+func timeoutOrDefault(timeout, def time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+
+	return def
+}
@@ -0,0 +1,164 @@
+package harvester
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	"golang.org/x/net/html"
+)
+
+// HTML is a harvester that harvests data from a static HTML page
+// without launching a browser.
+type HTML struct{}
+
+// Harvest harvests data from a static HTML page using a plan.
+func (HTML) Harvest(ctx context.Context, p *plan.Plan) (map[string]any, error) {
+	body, err := fetch(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source: %w", err)
+	}
+
+	harvested := harvestDocument(ctx, p.Fields, doc.Selection)
+	markMissing(p.Fields, harvested)
+
+	return harvested, nil
+}
+
+// findFirst returns the nodes matched by the first of the selectors of a field matching any, and that selector.
+func findFirst(root *goquery.Selection, field *plan.Field) (*goquery.Selection, string) {
+	selectors := field.AllSelectors()
+	for _, selector := range selectors[:len(selectors)-1] {
+		if nodes := root.Find(selector); nodes.Length() > 0 {
+			return nodes, selector
+		}
+	}
+
+	last := selectors[len(selectors)-1]
+
+	return root.Find(last), last
+}
+
+func harvestDocument(ctx context.Context, fields []plan.Field, root *goquery.Selection) map[string]any {
+	harvested := make(map[string]any)
+
+	for i := range fields {
+		nodes, selector := findFirst(root, &fields[i])
+		field := *fields[i].WithSelector(selector)
+
+		logger.Log.Debugw("querying", "name", field.Name, "selector", field.Selector, "nodes", nodes.Length())
+
+		nodes = nodes.Slice(field.Pick(nodes.Length()))
+
+		switch {
+		case field.Type == converter.TypeObject:
+			if nodes.Length() > 0 {
+				harvested[field.Name] = harvestDocument(ctx, field.Fields, nodes.First())
+			}
+		case field.Type == converter.TypeList:
+			records := make([]map[string]any, 0, nodes.Length())
+			nodes.Each(func(_ int, node *goquery.Selection) {
+				records = append(records, harvestDocument(ctx, field.Fields, node))
+			})
+			harvested[field.Name] = records
+		default:
+			matched := nodes.Length()
+			if field.IsSingle() {
+				// Only the first node is needed.
+				nodes = nodes.First()
+			}
+
+			values := make([]string, 0, nodes.Length())
+			nodes.Each(func(_ int, node *goquery.Selection) {
+				if val, ok := extractNode(ctx, &field, node); ok {
+					values = append(values, val)
+				}
+			})
+
+			if val, ok := shapeValues(&field, values, matched); ok {
+				harvested[field.Name] = val
+			}
+		}
+	}
+
+	return harvested
+}
+
+func extractNode(ctx context.Context, field *plan.Field, node *goquery.Selection) (string, bool) {
+	switch {
+	case field.Attribute != "":
+		return node.Attr(field.Attribute)
+	case field.Property != "":
+		return selectionProperty(ctx, field, node)
+	case field.Type == converter.TypeRaw:
+		return selectionOuterHTML(ctx, field, node)
+	case field.Extract == ExtractFull:
+		return normalizeSpace(node.Text()), true
+	}
+
+	// Like the website harvester, whitespace-only text nodes e.g indentation are skipped.
+	first := node.Get(0).FirstChild
+	for first != nil && first.Type == html.TextNode && strings.TrimSpace(first.Data) == "" {
+		first = first.NextSibling
+	}
+
+	if first == nil || first.Type != html.TextNode {
+		logger.Log.WarnwContext(ctx,
+			"skipping node without a leading text node",
+			"name", field.Name, "selector", field.Selector,
+		)
+
+		return "", false
+	}
+
+	return first.Data, true
+}
+
+// selectionProperty approximates the DOM properties of a node available in a browser.
+// Unknown properties fall back to the attribute of the same name.
+func selectionProperty(ctx context.Context, field *plan.Field, node *goquery.Selection) (string, bool) {
+	switch field.Property {
+	case "textContent", "innerText":
+		return node.Text(), true
+	case "innerHTML":
+		inner, err := node.Html()
+		if err != nil {
+			logger.Log.ErrorwContext(ctx,
+				"failed to get inner HTML",
+				"name", field.Name, "selector", field.Selector, "error", err,
+			)
+
+			return "", false
+		}
+
+		return inner, true
+	case "outerHTML":
+		return selectionOuterHTML(ctx, field, node)
+	default:
+		return node.Attr(field.Property)
+	}
+}
+
+func selectionOuterHTML(ctx context.Context, field *plan.Field, node *goquery.Selection) (string, bool) {
+	outer, err := goquery.OuterHtml(node)
+	if err != nil {
+		logger.Log.ErrorwContext(ctx,
+			"failed to get outer HTML",
+			"name", field.Name, "selector", field.Selector, "error", err,
+		)
+
+		return "", false
+	}
+
+	return outer, true
+}
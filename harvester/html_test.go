@@ -0,0 +1,334 @@
+package harvester_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+)
+
+var _ = Describe("HTML", func() {
+	b, err := ioutil.ReadFile("testdata/website.html")
+	Expect(err).To(BeNil())
+
+	ts := httptest.NewServer(writeHTML(string(b)))
+	AfterEach(ts.Close)
+
+	p := plan.Plan{
+		Source: ts.URL,
+		Type:   harvester.TypeHTML,
+		Fields: []plan.Field{
+			{
+				Name:     "raw",
+				Type:     converter.TypeRaw,
+				Selector: "#app > p.raw",
+			},
+			{
+				Name:     "text",
+				Type:     converter.TypeText,
+				Selector: "#app > p.text",
+			},
+			{
+				Name:     "textList",
+				Type:     converter.TypeText,
+				Selector: "#app > ul.text-list > li",
+			},
+			{
+				Name:     "number",
+				Type:     converter.TypeNumber,
+				Selector: "#app > p.number",
+			},
+			{
+				Name:     "numberWithText",
+				Type:     converter.TypeNumber,
+				Selector: "#app > p.number-with-text",
+			},
+			{
+				Name:     "decimal",
+				Type:     converter.TypeDecimal,
+				Selector: "#app > p.decimal",
+			},
+			{
+				Name:     "decimalWithText",
+				Type:     converter.TypeDecimal,
+				Selector: "#app > p.decimal-with-text",
+			},
+			{
+				Name:     "datetime",
+				Type:     converter.TypeDateTime,
+				Selector: "#app > p.datetime",
+			},
+			{
+				Name:     "datetimeWithText",
+				Type:     converter.TypeDateTime,
+				Selector: "#app > p.datetime-with-text",
+			},
+			{
+				Name:     "fullText",
+				Type:     converter.TypeText,
+				Selector: "#app > p.full-text",
+				Extract:  harvester.ExtractFull,
+			},
+			{
+				Name:      "description",
+				Type:      converter.TypeText,
+				Selector:  "head > meta[name=description]",
+				Attribute: "content",
+			},
+			{
+				Name:      "links",
+				Type:      converter.TypeText,
+				Selector:  "#app > nav.links > a",
+				Attribute: "href",
+			},
+			{
+				Name:     "input",
+				Type:     converter.TypeText,
+				Selector: "#app > input.input",
+				Property: "value",
+			},
+			{
+				Name:     "experience",
+				Type:     converter.TypeList,
+				Selector: "#app > ul.experience > li",
+				Fields: []plan.Field{
+					{
+						Name:     "company",
+						Type:     converter.TypeText,
+						Selector: "h3",
+					},
+					{
+						Name:     "period",
+						Type:     converter.TypeText,
+						Selector: "span",
+					},
+				},
+			},
+		},
+	}
+
+	expected := map[string]any{
+		"raw":  `<p class="raw">Get html!</p>`,
+		"text": "Some t3xt!",
+		"textList": []string{
+			"1Sw0C0tlYNfC2ookd5lr",
+			"ifpTMDlSfhMSCD",
+			"kRaQ5Lqtrbrk1oEq",
+			"Q9g17hjV",
+			"hUPwfr1GKzaHkMmENn",
+		},
+		"number":           "1337",
+		"numberWithText":   "This is some leet number: 1337",
+		"decimal":          "13.37",
+		"decimalWithText":  "This is some leet decimal: 13.37",
+		"datetime":         "08/06/2022 19:53:44",
+		"datetimeWithText": "This is some random datetime: 08/06/2022 19:53:44",
+		"fullText":         "Price: 42 EUR",
+		"description":      "A website to test harvit",
+		"links": []string{
+			"https://github.com/mgjules",
+			"https://mgjules.dev",
+		},
+		"input": "Some input",
+		"experience": []map[string]any{
+			{"company": "Ringier SA", "period": "01/2021 → Present"},
+			{"company": "Bocasay", "period": "01/2020 → 02/2021"},
+		},
+	}
+
+	_, err = logger.New(false)
+	Expect(err).To(BeNil())
+
+	h, err := harvester.New(p.Type)
+	Expect(err).To(BeNil())
+
+	It("should successfully harvest the data", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		data, err := h.Harvest(ctx, &p)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(expected))
+	})
+
+	It("should harvest the same data from a saved page", func() {
+		path, err := filepath.Abs("testdata/website.html")
+		Expect(err).To(BeNil())
+
+		saved := p
+		saved.Source = "file://" + path
+
+		data, err := h.Harvest(context.Background(), &saved)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(expected))
+	})
+
+	It("should harvest the same data from a HAR file", func() {
+		path, err := filepath.Abs("testdata/website.har")
+		Expect(err).To(BeNil())
+
+		archived := p
+		archived.Source = "file://" + path
+
+		data, err := h.Harvest(context.Background(), &archived)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(expected))
+
+		archived.Source = "https://example.com/jobs"
+		archived.Archive = path
+
+		data, err = h.Harvest(context.Background(), &archived)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(expected))
+
+		archived.Source = "https://example.com/elsewhere"
+
+		_, err = h.Harvest(context.Background(), &archived)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should harvest the values in the declared cardinality", func() {
+		path, err := filepath.Abs("testdata/website.html")
+		Expect(err).To(BeNil())
+
+		shaped := p
+		shaped.Source = "file://" + path
+		shaped.Fields = []plan.Field{
+			{
+				Name:     "text",
+				Type:     converter.TypeText,
+				Selector: "#app > p.text",
+				Multiple: lo.ToPtr(true),
+			},
+			{
+				Name:     "textList",
+				Type:     converter.TypeText,
+				Selector: "#app > ul.text-list > li",
+				Multiple: lo.ToPtr(false),
+			},
+			{
+				Name:     "nothing",
+				Type:     converter.TypeText,
+				Selector: "#app > p.nothing",
+				Multiple: lo.ToPtr(true),
+			},
+		}
+
+		data, err := h.Harvest(context.Background(), &shaped)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"text":               []string{"Some t3xt!"},
+			"textList":           "1Sw0C0tlYNfC2ookd5lr",
+			harvester.MissingKey: []string{"nothing"},
+		}))
+	})
+
+	It("should harvest the picked nodes only", func() {
+		path, err := filepath.Abs("testdata/website.html")
+		Expect(err).To(BeNil())
+
+		picked := p
+		picked.Source = "file://" + path
+		picked.Fields = []plan.Field{
+			{Name: "first", Type: converter.TypeText, Selector: "#app > ul.text-list > li", First: true},
+			{Name: "last", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Last: true},
+			{Name: "index", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Index: lo.ToPtr(-2)},
+			{Name: "slice", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Slice: "1:3"},
+			{Name: "limit", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Slice: "2:", Limit: 2},
+			{
+				Name:     "experience",
+				Type:     converter.TypeList,
+				Selector: "#app > ul.experience > li",
+				Last:     true,
+				Fields:   []plan.Field{{Name: "company", Type: converter.TypeText, Selector: "h3"}},
+			},
+		}
+
+		for i := range picked.Fields {
+			Expect(picked.Fields[i].Compile()).To(Succeed())
+		}
+
+		data, err := h.Harvest(context.Background(), &picked)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"first":      "1Sw0C0tlYNfC2ookd5lr",
+			"last":       "hUPwfr1GKzaHkMmENn",
+			"index":      "Q9g17hjV",
+			"slice":      []string{"ifpTMDlSfhMSCD", "kRaQ5Lqtrbrk1oEq"},
+			"limit":      []string{"kRaQ5Lqtrbrk1oEq", "Q9g17hjV"},
+			"experience": []map[string]any{{"company": "Bocasay"}},
+		}))
+	})
+
+	It("should harvest the first fallback selector matching", func() {
+		path, err := filepath.Abs("testdata/website.html")
+		Expect(err).To(BeNil())
+
+		fallback := p
+		fallback.Source = "file://" + path
+		fallback.Fields = []plan.Field{
+			{
+				Name:      "text",
+				Type:      converter.TypeText,
+				Selectors: []string{"#app > p.variant", "#app > p.text", "#app > p.number"},
+			},
+			{
+				Name:      "nothing",
+				Type:      converter.TypeText,
+				Selectors: []string{"#app > p.variant", "#app > p.other-variant"},
+			},
+		}
+
+		data, err := h.Harvest(context.Background(), &fallback)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"text":               "Some t3xt!",
+			harvester.MissingKey: []string{"nothing"},
+		}))
+	})
+
+	It("should skip the indentation before the leading text node", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "indented.html")
+		Expect(os.WriteFile(path, []byte(`<!DOCTYPE html><html><body>
+<div class="nested">
+  <span>Gopher</span>
+</div>
+<div class="text">
+  Rustacean
+  <span>Pythonista</span>
+</div>
+</body></html>`), 0o600)).To(Succeed())
+
+		indented := p
+		indented.Source = "file://" + path
+		indented.Fields = []plan.Field{
+			{Name: "nested", Type: converter.TypeText, Selector: "div.nested"},
+			{Name: "text", Type: converter.TypeText, Selector: "div.text"},
+		}
+
+		data, err := h.Harvest(context.Background(), &indented)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"text":               "\n  Rustacean\n  ",
+			harvester.MissingKey: []string{"nested"},
+		}))
+	})
+})
@@ -0,0 +1,91 @@
+package harvester
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+)
+
+// fetch requests the source of a plan over HTTP and returns the response body.
+// Sources are read from the plan archive, if any, and file:// sources from disk;
+// the crawler only passes file:// URLs when the plan source itself is local.
+// The caller is responsible for closing the body.
+func fetch(ctx context.Context, p *plan.Plan) (io.ReadCloser, error) {
+	u, err := url.Parse(p.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source URL: %w", err)
+	}
+
+	if len(p.Steps) > 0 {
+		logger.Log.WarnwContext(ctx, "ignoring steps, only the website harvester performs them", "type", p.Type)
+	}
+
+	if p.Archive != "" {
+		return fetchArchived(p)
+	}
+
+	if u.Scheme == "file" {
+		f, err := os.Open(filepath.Clean(u.Path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open source: %w", err)
+		}
+
+		return f, nil
+	}
+
+	var body io.Reader = http.NoBody
+	if p.Request.Body != "" {
+		body = strings.NewReader(p.Request.Body)
+	}
+
+	method := p.Request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.Source, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", pickUserAgent(p.UserAgents))
+	for k, v := range p.Request.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: timeoutOrDefault(p.Timeout, DefaultTimeout)}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch source: %w", err)
+	}
+
+	if rec := recorderFrom(ctx); rec != nil {
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source: %w", err)
+		}
+
+		rec.record(method, p.Source, resp.StatusCode, resp.Header, b)
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		resp.Body.Close()
+
+		return nil, fmt.Errorf("failed to fetch source: unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,157 @@
+package harvester
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mgjules/harvit/logger"
+)
+
+type recorderKey struct{}
+
+// Recorder records the responses fetched by the harvesters into an archive.
+type Recorder struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	archive Archive
+}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	r := &Recorder{}
+	r.archive.Log.Version = "1.2"
+	r.archive.Log.Creator.Name = "harvit"
+	r.archive.Log.Entries = make([]ArchiveEntry, 0)
+
+	return r
+}
+
+// WithRecorder returns a context recording the responses fetched by harvesters using it.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+func recorderFrom(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+
+	return r
+}
+
+// Archive returns the recorded responses, once the pending ones are recorded.
+func (r *Recorder) Archive() *Archive {
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a := r.archive
+	a.Log.Entries = append([]ArchiveEntry(nil), r.archive.Log.Entries...)
+
+	return &a
+}
+
+// record adds a response to the archive.
+func (r *Recorder) record(method, url string, status int, headers http.Header, body []byte) {
+	var entry ArchiveEntry
+	entry.Request.Method = method
+	entry.Request.URL = url
+	entry.Response.Status = status
+	entry.Response.Content.MimeType = headers.Get("Content-Type")
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range headers[name] {
+			entry.Response.Headers = append(entry.Response.Headers, ArchiveHeader{Name: name, Value: value})
+		}
+	}
+
+	if utf8.Valid(body) {
+		entry.Response.Content.Text = string(body)
+	} else {
+		entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+		entry.Response.Content.Encoding = "base64"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.archive.Log.Entries = append(r.archive.Log.Entries, entry)
+}
+
+// recordNetwork returns an action recording every response received by the browser tab.
+func recordNetwork(r *Recorder) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var (
+			mu        sync.Mutex
+			methods   = make(map[network.RequestID]string)
+			responses = make(map[network.RequestID]*network.Response)
+		)
+
+		chromedp.ListenTarget(ctx, func(ev any) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch e := ev.(type) {
+			case *network.EventRequestWillBeSent:
+				if e.RedirectResponse != nil {
+					// Redirects reuse the request ID, record the redirect itself.
+					r.record(methods[e.RequestID], e.RedirectResponse.URL, int(e.RedirectResponse.Status),
+						networkHeaders(e.RedirectResponse.Headers), nil)
+				}
+
+				methods[e.RequestID] = e.Request.Method
+			case *network.EventResponseReceived:
+				responses[e.RequestID] = e.Response
+			case *network.EventLoadingFinished:
+				resp, found := responses[e.RequestID]
+				if !found {
+					return
+				}
+
+				method := methods[e.RequestID]
+				delete(responses, e.RequestID)
+
+				r.wg.Add(1)
+
+				go func(id network.RequestID) {
+					defer r.wg.Done()
+
+					execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+
+					body, err := network.GetResponseBody(id).Do(execCtx)
+					if err != nil {
+						logger.Log.WarnwContext(ctx, "failed to record response", "url", resp.URL, "error", err)
+
+						return
+					}
+
+					r.record(method, resp.URL, int(resp.Status), networkHeaders(resp.Headers), body)
+				}(e.RequestID)
+			}
+		})
+
+		return nil
+	})
+}
+
+func networkHeaders(headers network.Headers) http.Header {
+	h := make(http.Header, len(headers))
+	for name, value := range headers {
+		h.Add(name, fmt.Sprint(value))
+	}
+
+	return h
+}
@@ -0,0 +1,64 @@
+package harvester_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recorder", func() {
+	BeforeEach(func() {
+		_, err := logger.New(false)
+		Expect(err).To(BeNil())
+	})
+
+	It("should replay the recorded responses", func() {
+		ts := httptest.NewServer(writeHTML(`
+<html>
+	<body>
+		<h1>Jobs</h1>
+		<a class="next" href="/page/2">Next</a>
+	</body>
+</html>`))
+
+		p := plan.Plan{
+			Source: ts.URL,
+			Type:   harvester.TypeHTML,
+			Fields: []plan.Field{
+				{Name: "titles", Type: converter.TypeText, Selector: "h1"},
+			},
+			Pagination: &plan.Pagination{Next: "a.next", MaxPages: 2},
+		}
+
+		h, err := harvester.New(p.Type)
+		Expect(err).To(BeNil())
+
+		rec := harvester.NewRecorder()
+
+		recorded, err := h.Harvest(harvester.WithRecorder(context.Background(), rec), &p)
+		Expect(err).To(BeNil())
+		Expect(recorded).To(HaveKeyWithValue("titles", []string{"Jobs", "Jobs"}))
+
+		ts.Close()
+
+		path := filepath.Join(GinkgoT().TempDir(), "jobs.har")
+		Expect(rec.Archive().Save(path)).To(Succeed())
+
+		a, err := harvester.LoadArchive(path)
+		Expect(err).To(BeNil())
+		Expect(a.Log.Entries).To(HaveLen(2))
+
+		p.Archive = path
+
+		replayed, err := h.Harvest(context.Background(), &p)
+		Expect(err).To(BeNil())
+		Expect(replayed).To(Equal(recorded))
+	})
+})
@@ -0,0 +1,93 @@
+package harvester
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/plan"
+)
+
+// Step actions.
+const (
+	StepClick  = "click"
+	StepFill   = "fill"
+	StepSelect = "select"
+	StepScroll = "scroll"
+	StepWait   = "wait"
+	StepEval   = "eval"
+)
+
+const scrollToBottom = `window.scrollTo(0, document.body.scrollHeight)`
+
+// compileStepActions compiles the browser steps of a plan into chromedp actions.
+func compileStepActions(steps []plan.Step, actions []chromedp.Action) ([]chromedp.Action, error) {
+	for i := range steps {
+		step := steps[i]
+
+		switch step.Action {
+		case StepClick:
+			actions = append(actions, chromedp.Click(step.Selector, chromedp.NodeVisible))
+		case StepFill:
+			actions = append(actions,
+				chromedp.Clear(step.Selector),
+				chromedp.SendKeys(step.Selector, step.Value),
+			)
+		case StepSelect:
+			actions = append(actions, selectOption(step.Selector, step.Value))
+		case StepScroll:
+			for j := 0; j < step.Times; j++ {
+				actions = append(actions,
+					chromedp.Evaluate(scrollToBottom, nil),
+					chromedp.Sleep(step.Duration),
+				)
+			}
+		case StepWait:
+			switch {
+			case step.Selector != "" && step.Visible:
+				actions = append(actions, chromedp.WaitVisible(step.Selector))
+			case step.Selector != "":
+				actions = append(actions, chromedp.WaitReady(step.Selector))
+			case step.Duration > 0:
+				actions = append(actions, chromedp.Sleep(step.Duration))
+			default:
+				return nil, fmt.Errorf("step %d: wait needs a selector or a duration", i)
+			}
+		case StepEval:
+			actions = append(actions, chromedp.Evaluate(step.Value, nil))
+		default:
+			return nil, fmt.Errorf("step %d: unknown action: %s", i, step.Action)
+		}
+	}
+
+	return actions, nil
+}
+
+// selectOption selects the option of a select element by value
+// and dispatches the events a user selection would.
+func selectOption(sel, value string) chromedp.QueryAction {
+	return chromedp.QueryAfter(sel,
+		func(ctx context.Context, eci runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+			if len(nodes) < 1 {
+				return fmt.Errorf("selector %q did not return any nodes", sel)
+			}
+
+			quoted, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to marshal value: %w", err)
+			}
+
+			_, err = callFunctionOnNode(ctx, nodes[0], fmt.Sprintf(`function() {
+				this.value = %s;
+				this.dispatchEvent(new Event("input", { bubbles: true }));
+				this.dispatchEvent(new Event("change", { bubbles: true }));
+			}`, quoted))
+
+			return err
+		},
+		chromedp.NodeVisible,
+	)
+}
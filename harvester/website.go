@@ -5,17 +5,21 @@ import (
 	"fmt"
 	"math/rand"
 	"net/url"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
-	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/json"
 	"github.com/mgjules/harvit/logger"
 	"github.com/mgjules/harvit/plan"
 )
 
+// selectorPollInterval is the interval between attempts at extracting the fields whose selector did not match yet.
+const selectorPollInterval = 100 * time.Millisecond
+
 // Website is a harvester that harvests data from a website.
 type Website struct{}
 
@@ -25,39 +29,74 @@ func (Website) Harvest(ctx context.Context, p *plan.Plan) (map[string]any, error
 		return nil, fmt.Errorf("failed to parse source URL: %w", err)
 	}
 
-	// create context
+	// create context, a new tab if ctx holds a browser from NewBrowser
 	ctx, cancel := chromedp.NewContext(ctx)
 	defer cancel()
 
-	var userAgent string
-	if len(p.UserAgents) > 0 {
-		userAgent = p.UserAgents[rand.Intn(len(p.UserAgents))] //nolint:gosec
-	} else {
-		userAgent = uaGens[rand.Intn(len(uaGens))]() //nolint:gosec
-	}
+	userAgent := pickUserAgent(p.UserAgents)
 
 	harvested := make(map[string]any)
 
-	actions := []chromedp.Action{
+	var actions []chromedp.Action
+	if p.Archive != "" {
+		a, err := LoadArchive(p.Archive)
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, replayArchive(a))
+	}
+
+	rec := recorderFrom(ctx)
+	if rec != nil {
+		actions = append(actions, recordNetwork(rec))
+	}
+
+	actions = append(actions,
 		network.Enable(),
 		network.SetExtraHTTPHeaders(
 			network.Headers(map[string]interface{}{
 				"User-Agent": userAgent,
 			}),
 		),
-		chromedp.Navigate(p.Source),
+	)
+
+	steps, err := compileStepActions(p.Steps, []chromedp.Action{chromedp.Navigate(p.Source)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile steps: %w", err)
 	}
 
+	actions = append(actions, withTimeout(timeoutOrDefault(p.Timeout, DefaultTimeout), steps...))
+
 	harvested, actions = compileFieldActions(p.Fields, harvested, actions)
 
 	if err := chromedp.Run(ctx, actions...); err != nil {
 		return nil, fmt.Errorf("failed to navigate to source: %w", err)
 	}
 
+	if rec != nil {
+		// Response bodies can only be recorded while the tab is open.
+		rec.wg.Wait()
+	}
+
+	markMissing(p.Fields, harvested)
+
 	return harvested, nil
 }
 
-//nolint:gocognit
+// withTimeout returns an action running the given actions within a timeout.
+func withTimeout(timeout time.Duration, actions ...chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return chromedp.Tasks(actions).Do(ctx)
+	})
+}
+
+// compileFieldActions appends an action harvesting the fields with a single evaluation of the extraction script
+// per attempt. Fields are extracted again every selectorPollInterval until their selector matches or their
+// timeout elapses, except for optional fields.
 func compileFieldActions(
 	fields []plan.Field,
 	harvested map[string]any,
@@ -67,67 +106,86 @@ func compileFieldActions(
 		return harvested, actions
 	}
 
-	for i := range fields {
-		field := fields[i]
-
-		actions = append(
-			actions,
-			chromedp.QueryAfter(field.Selector,
-				func(ctx context.Context, eci runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-					logger.Log.Debugw("querying", "name", field.Name, "selector", field.Selector, "nodes", nodes)
-
-					if len(nodes) > 1 {
-						harvested[field.Name] = make([]string, 0)
-						for i := range nodes {
-							if field.Type == converter.TypeRaw {
-								html, err := dom.GetOuterHTML().WithNodeID(nodes[i].NodeID).Do(ctx)
-								if err != nil {
-									logger.Log.ErrorwContext(ctx,
-										"failed to get outer HTML",
-										"name", field.Name, "selector", field.Selector, "node", nodes[i],
-									)
-
-									continue
-								}
-
-								harvested[field.Name] = html
-
-								continue
-							}
-
-							if nodes[i].ChildNodeCount == 0 || nodes[i].Children[0].NodeType != cdp.NodeTypeText {
-								continue
-							}
-
-							harvested[field.Name] = append( //nolint:forcetypeassert
-								harvested[field.Name].([]string),
-								nodes[i].Children[0].NodeValue,
-							)
-						}
-					} else if len(nodes) == 1 {
-						if field.Type == converter.TypeRaw {
-							html, err := dom.GetOuterHTML().WithNodeID(nodes[0].NodeID).Do(ctx)
-							if err != nil {
-								logger.Log.ErrorwContext(ctx,
-									"failed to get outer HTML",
-									"name", field.Name, "selector", field.Selector, "node", nodes[0],
-								)
-							} else {
-								harvested[field.Name] = html
-							}
-						} else if nodes[0].ChildNodeCount > 0 &&
-							nodes[0].Children[0].NodeType == cdp.NodeTypeText {
-							harvested[field.Name] = nodes[0].Children[0].NodeValue
-						}
-					}
-
-					return nil
-				},
-			),
-		)
+	return harvested, append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+		start := time.Now()
+		pending := fields
+
+		for {
+			expr, err := extractExpression(pending)
+			if err != nil {
+				return err
+			}
+
+			var extracted map[string]extraction
+			if err := chromedp.Evaluate(expr, &extracted).Do(ctx); err != nil {
+				return fmt.Errorf("failed to extract fields: %w", err)
+			}
+
+			var waiting []plan.Field
+			for i := range pending {
+				field := &pending[i]
+				ex := extracted[field.Name]
+				timeout := timeoutOrDefault(field.Timeout, DefaultFieldTimeout)
+
+				switch {
+				case ex.Selector >= 0 || field.Optional:
+					harvestExtraction(ctx, field, ex, harvested)
+				case time.Since(start) >= timeout:
+					logger.Log.WarnwContext(ctx,
+						"timed out waiting for field",
+						"name", field.Name, "selectors", field.AllSelectors(), "timeout", timeout,
+					)
+				default:
+					waiting = append(waiting, *field)
+				}
+			}
+
+			if len(waiting) == 0 {
+				return nil
+			}
+
+			pending = waiting
+
+			select {
+			case <-time.After(selectorPollInterval):
+			case <-ctx.Done():
+				return fmt.Errorf("failed to extract fields: %w", ctx.Err())
+			}
+		}
+	}))
+}
+
+// callFunctionOnNode calls a JavaScript function with the node as this
+// and returns its JSON encoded result.
+func callFunctionOnNode(ctx context.Context, node *cdp.Node, function string) (json.RawMessage, error) {
+	obj, err := dom.ResolveNode().WithNodeID(node.NodeID).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve node: %w", err)
+	}
+
+	res, exp, err := runtime.CallFunctionOn(function).
+		WithObjectID(obj.ObjectID).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call function on node: %w", err)
+	}
+
+	if exp != nil {
+		return nil, exp
+	}
+
+	return json.RawMessage(res.Value), nil
+}
+
+// pickUserAgent returns a random user agent from the given list
+// or a generated one if the list is empty.
+func pickUserAgent(userAgents []string) string {
+	if len(userAgents) > 0 {
+		return userAgents[rand.Intn(len(userAgents))] //nolint:gosec
 	}
 
-	return harvested, actions
+	return uaGens[rand.Intn(len(uaGens))]() //nolint:gosec
 }
 
 var uaGens = []func() string{
@@ -0,0 +1,127 @@
+package harvester_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mgjules/harvit/converter"
+	"github.com/mgjules/harvit/harvester"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+)
+
+// BenchmarkWebsiteLargePlan harvests a plan of 100 fields, half of them reading a single node
+// out of lists of 20 links, to measure the cost of extracting every field in a single evaluation
+// against a baseline querying each field separately.
+func BenchmarkWebsiteLargePlan(b *testing.B) {
+	if _, err := logger.New(false); err != nil {
+		b.Fatal(err)
+	}
+
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html><html><body>")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&page, `<p class="f%d">value %d</p><ul class="l%d">`, i, i, i)
+		for j := 0; j < 20; j++ {
+			fmt.Fprintf(&page, `<li><a href="/jobs/%d">job %d</a></li>`, j, j)
+		}
+		page.WriteString("</ul>")
+	}
+	page.WriteString("</body></html>")
+
+	ts := httptest.NewServer(writeHTML(page.String()))
+	defer ts.Close()
+
+	p := plan.Plan{Source: ts.URL, Type: harvester.TypeWebsite}
+	for i := 0; i < 50; i++ {
+		p.Fields = append(p.Fields,
+			plan.Field{Name: fmt.Sprintf("f%d", i), Type: converter.TypeText, Selector: fmt.Sprintf("p.f%d", i)},
+			plan.Field{
+				Name:      fmt.Sprintf("l%d", i),
+				Type:      converter.TypeText,
+				Selector:  fmt.Sprintf("ul.l%d a", i),
+				Attribute: "href",
+				Last:      true,
+			},
+		)
+	}
+
+	ctx, cancel, err := harvester.NewBrowser(context.Background())
+	if err != nil {
+		b.Skip("no browser:", err)
+	}
+	defer cancel()
+
+	h, err := harvester.New(p.Type)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Warm up the browser, skipping when Chrome is not installed.
+	if _, err := h.Harvest(ctx, &p); err != nil {
+		b.Skip("no browser:", err)
+	}
+
+	b.Run("script", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			data, err := h.Harvest(ctx, &p)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if len(data) != len(p.Fields) {
+				b.Fatalf("harvested %d fields, want %d", len(data), len(p.Fields))
+			}
+		}
+	})
+
+	// The baseline queries the nodes of each field and reads their text one by one,
+	// as the harvester did before extracting every field in a single evaluation.
+	b.Run("baseline", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := harvestPerNode(ctx, &p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// harvestPerNode harvests the fields of a plan with a round trip to the browser per field and per text node.
+func harvestPerNode(ctx context.Context, p *plan.Plan) error {
+	ctx, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+
+	return chromedp.Run(ctx,
+		chromedp.Navigate(p.Source),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			for i := range p.Fields {
+				var nodes []*cdp.Node
+				err := chromedp.Nodes(p.Fields[i].Selector, &nodes, chromedp.ByQueryAll, chromedp.AtLeast(0)).Do(ctx)
+				if err != nil {
+					return err
+				}
+
+				for _, node := range nodes {
+					if p.Fields[i].Attribute != "" {
+						node.AttributeValue(p.Fields[i].Attribute)
+
+						continue
+					}
+
+					var text string
+					err := chromedp.JavascriptAttribute([]cdp.NodeID{node.NodeID}, "textContent", &text, chromedp.ByNodeID).Do(ctx)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		}),
+	)
+}
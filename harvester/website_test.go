@@ -15,18 +15,29 @@ import (
 	"github.com/mgjules/harvit/plan"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 )
 
-var _ = Describe("Website", func() {
+var _ = Describe("Website", Ordered, func() {
 	b, err := ioutil.ReadFile("testdata/website.html")
 	Expect(err).To(BeNil())
 
 	ts := httptest.NewServer(writeHTML(string(b)))
-	AfterEach(ts.Close)
+	AfterAll(ts.Close)
 
 	p := plan.Plan{
 		Source: ts.URL,
 		Type:   harvester.TypeWebsite,
+		Steps: []plan.Step{
+			{
+				Action:   harvester.StepClick,
+				Selector: "#load-more",
+			},
+			{
+				Action:   harvester.StepWait,
+				Selector: "#more > p.loaded",
+			},
+		},
 		Fields: []plan.Field{
 			{
 				Name:     "raw",
@@ -73,6 +84,58 @@ var _ = Describe("Website", func() {
 				Type:     converter.TypeDateTime,
 				Selector: "#app > p.datetime-with-text",
 			},
+			{
+				Name:     "fullText",
+				Type:     converter.TypeText,
+				Selector: "#app > p.full-text",
+				Extract:  harvester.ExtractFull,
+			},
+			{
+				Name:      "description",
+				Type:      converter.TypeText,
+				Selector:  "head > meta[name=description]",
+				Attribute: "content",
+			},
+			{
+				Name:      "links",
+				Type:      converter.TypeText,
+				Selector:  "#app > nav.links > a",
+				Attribute: "href",
+			},
+			{
+				Name:     "input",
+				Type:     converter.TypeText,
+				Selector: "#app > input.input",
+				Property: "value",
+			},
+			{
+				Name:     "loaded",
+				Type:     converter.TypeText,
+				Selector: "#more > p.loaded",
+			},
+			{
+				Name:     "xpath",
+				Type:     converter.TypeText,
+				Selector: "//ul[@class='text-list']/li",
+				Last:     true,
+			},
+			{
+				Name:     "experience",
+				Type:     converter.TypeList,
+				Selector: "#app > ul.experience > li",
+				Fields: []plan.Field{
+					{
+						Name:     "company",
+						Type:     converter.TypeText,
+						Selector: "h3",
+					},
+					{
+						Name:     "period",
+						Type:     converter.TypeText,
+						Selector: "span",
+					},
+				},
+			},
 		},
 	}
 
@@ -92,6 +155,19 @@ var _ = Describe("Website", func() {
 		"decimalWithText":  "This is some leet decimal: 13.37",
 		"datetime":         "08/06/2022 19:53:44",
 		"datetimeWithText": "This is some random datetime: 08/06/2022 19:53:44",
+		"fullText":         "Price: 42 EUR",
+		"description":      "A website to test harvit",
+		"links": []string{
+			"https://github.com/mgjules",
+			"https://mgjules.dev",
+		},
+		"input":  "Some input",
+		"loaded": "Loaded!",
+		"xpath":  "hUPwfr1GKzaHkMmENn",
+		"experience": []map[string]any{
+			{"company": "Ringier SA", "period": "01/2021 → Present"},
+			{"company": "Bocasay", "period": "01/2020 → 02/2021"},
+		},
 	}
 
 	_, err = logger.New(false)
@@ -109,6 +185,134 @@ var _ = Describe("Website", func() {
 
 		Expect(data).To(BeEquivalentTo(expected))
 	})
+
+	It("should extract the picked nodes only", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		picked := plan.Plan{
+			Source: ts.URL,
+			Type:   harvester.TypeWebsite,
+			Fields: []plan.Field{
+				{Name: "first", Type: converter.TypeText, Selector: "#app > ul.text-list > li", First: true},
+				{Name: "index", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Index: lo.ToPtr(-2)},
+				{Name: "slice", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Slice: "1:3"},
+				{Name: "limit", Type: converter.TypeText, Selector: "#app > ul.text-list > li", Slice: "2:", Limit: 2},
+				{
+					Name:     "single",
+					Type:     converter.TypeText,
+					Selector: "#app > ul.text-list > li",
+					Multiple: lo.ToPtr(false),
+				},
+				{
+					Name:     "experience",
+					Type:     converter.TypeObject,
+					Selector: "#app > ul.experience > li",
+					Last:     true,
+					Fields:   []plan.Field{{Name: "company", Type: converter.TypeText, Selector: "h3"}},
+				},
+			},
+		}
+
+		for i := range picked.Fields {
+			Expect(picked.Fields[i].Compile()).To(Succeed())
+		}
+
+		data, err := h.Harvest(ctx, &picked)
+		Expect(err).To(BeNil())
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"first":      "1Sw0C0tlYNfC2ookd5lr",
+			"index":      "Q9g17hjV",
+			"slice":      []string{"ifpTMDlSfhMSCD", "kRaQ5Lqtrbrk1oEq"},
+			"limit":      []string{"kRaQ5Lqtrbrk1oEq", "Q9g17hjV"},
+			"single":     "1Sw0C0tlYNfC2ookd5lr",
+			"experience": map[string]any{"company": "Bocasay"},
+		}))
+	})
+
+	It("should return the other fields when a field times out", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		partial := plan.Plan{
+			Source: ts.URL,
+			Type:   harvester.TypeWebsite,
+			Fields: []plan.Field{
+				{
+					Name:     "missing",
+					Type:     converter.TypeText,
+					Selector: "#app > p.missing",
+					Timeout:  200 * time.Millisecond,
+				},
+				{
+					Name:     "optional",
+					Type:     converter.TypeText,
+					Selector: "#app > p.missing",
+					Optional: true,
+				},
+				{
+					Name:     "text",
+					Type:     converter.TypeText,
+					Selector: "#app > p.text",
+				},
+			},
+		}
+
+		start := time.Now()
+
+		data, err := h.Harvest(ctx, &partial)
+		Expect(err).To(BeNil())
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"text":               "Some t3xt!",
+			harvester.MissingKey: []string{"missing", "optional"},
+		}))
+	})
+
+	It("should harvest the first fallback selector matching without waiting for the others", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		fallback := plan.Plan{
+			Source: ts.URL,
+			Type:   harvester.TypeWebsite,
+			Fields: []plan.Field{
+				{
+					Name:      "text",
+					Type:      converter.TypeText,
+					Selectors: []string{"#app > p.variant", "#app > p.text", "#app > p.number"},
+				},
+				{
+					Name:     "experience",
+					Type:     converter.TypeList,
+					Selector: "#app > ul.experience > li",
+					Fields: []plan.Field{
+						{
+							Name:      "company",
+							Type:      converter.TypeText,
+							Selectors: []string{"h4", "h3"},
+						},
+					},
+				},
+			},
+		}
+
+		start := time.Now()
+
+		data, err := h.Harvest(ctx, &fallback)
+		Expect(err).To(BeNil())
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+
+		Expect(data).To(BeEquivalentTo(map[string]any{
+			"text": "Some t3xt!",
+			"experience": []map[string]any{
+				{"company": "Ringier SA"},
+				{"company": "Bocasay"},
+			},
+		}))
+	})
 })
 
 func writeHTML(content string) http.Handler {
@@ -18,5 +18,8 @@ var (
 	NewEncoder = json.NewEncoder
 )
 
+// Number refers to 'encoding/json.Number'.
+type Number = json.Number
+
 // RawMessage refers to 'encoding/json.RawMessage'.
 type RawMessage = json.RawMessage
@@ -23,5 +23,8 @@ var (
 	NewEncoder = json.NewEncoder
 )
 
+// Number refers to 'encoding/json.Number'.
+type Number = stdjson.Number
+
 // RawMessage refers to 'encoding/json.RawMessage'.
 type RawMessage = stdjson.RawMessage
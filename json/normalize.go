@@ -0,0 +1,18 @@
+package json
+
+import "fmt"
+
+// Normalize converts a value into generic maps, lists and scalars using its JSON representation.
+func Normalize(v any) (any, error) {
+	marshaled, err := Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	var normalized any
+	if err := Unmarshal(marshaled, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+
+	return normalized, nil
+}
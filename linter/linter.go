@@ -0,0 +1,437 @@
+package linter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/dop251/goja"
+	"github.com/go-playground/validator/v10"
+	"github.com/jmespath/go-jmespath"
+	"github.com/mgjules/harvit/output"
+	"github.com/mgjules/harvit/plan"
+	"github.com/robfig/cron/v3"
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a mistake found in a plan.
+type Problem struct {
+	// Line of the plan file the problem is on, 0 when unknown.
+	Line int `json:"line"`
+	// Path of the faulty value e.g "fields[2].regex".
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String formats the problem as "line: path: message".
+func (p Problem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("%d: %s", p.Line, p.Message)
+	}
+
+	return fmt.Sprintf("%d: %s: %s", p.Line, p.Path, p.Message)
+}
+
+// LintFile lints a plan file.
+func LintFile(path string) ([]Problem, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	return Lint(raw), nil
+}
+
+// Lint returns every problem found in a plan, sorted by line.
+// Besides the validation done when loading a plan, it checks the syntax of selectors,
+// regexes, the transformer, the notify section and the schedule, as well as duplicate field names.
+// Nothing is fetched.
+func Lint(raw []byte) []Problem {
+	l := &linter{}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(raw, &root); err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+
+	l.root = &root
+
+	var p plan.Plan
+	if err := yamlv2.Unmarshal(raw, &p); err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+
+	p.SetDefaults()
+
+	l.validate(&p)
+
+	l.selector = cssSelector
+	if p.Type == "api" {
+		l.selector = jmespathSelector
+	}
+
+	l.fields("fields", p.Fields)
+	l.steps(p.Steps)
+
+	if p.Pagination != nil && p.Pagination.Next != "" {
+		l.checkSelector("pagination.next", p.Pagination.Next)
+	}
+
+	if p.Follow != nil {
+		l.checkSelector("follow.selector", p.Follow.Selector)
+		l.fields("follow.fields", p.Follow.Fields)
+
+		for i := range p.Fields {
+			if p.Fields[i].Name == p.Follow.Name {
+				l.report("follow.name", "duplicate name %q, already used by fields[%d]", p.Follow.Name, i)
+			}
+		}
+	}
+
+	l.transformer(p.Transformer)
+	l.notify(p.Notify)
+
+	if p.Schedule != "" {
+		if _, err := cron.ParseStandard(p.Schedule); err != nil {
+			l.report("schedule", "invalid cron expression: %v", err)
+		}
+	}
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		return l.problems[i].Line < l.problems[j].Line
+	})
+
+	return l.problems
+}
+
+type linter struct {
+	root     *yaml.Node
+	selector func(string) error
+	problems []Problem
+}
+
+func (l *linter) report(path, format string, args ...any) {
+	l.problems = append(l.problems, Problem{
+		Line:    line(l.root, path),
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate runs the validation done when loading a plan, reporting every failure.
+func (l *linter) validate(p *plan.Plan) {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(yamlName)
+
+	err := validate.Struct(p)
+	if err == nil {
+		return
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		l.report("", "%v", err)
+
+		return
+	}
+
+	for _, fe := range errs {
+		// Drop the name of the root struct e.g "Plan.fields[0].name".
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		l.report(path, "%s", validationMessage(fe))
+	}
+}
+
+func (l *linter) fields(path string, fields []plan.Field) {
+	names := make(map[string]int, len(fields))
+
+	for i := range fields {
+		field := &fields[i]
+		fieldPath := fmt.Sprintf("%s[%d]", path, i)
+
+		if j, found := names[field.Name]; found {
+			l.report(fieldPath+".name", "duplicate name %q, already used by %s[%d]", field.Name, path, j)
+		} else {
+			names[field.Name] = i
+		}
+
+		if field.Selector != "" {
+			l.checkSelector(fieldPath+".selector", field.Selector)
+		}
+
+		for j, selector := range field.Selectors {
+			l.checkSelector(fmt.Sprintf("%s.selectors[%d]", fieldPath, j), selector)
+		}
+
+		if field.Regex != "" {
+			l.regex(fieldPath, field)
+		}
+
+		if field.Timezone != "" {
+			if _, err := time.LoadLocation(field.Timezone); err != nil {
+				l.report(fieldPath+".timezone", "unknown timezone: %v", err)
+			}
+		}
+
+		if field.Format != "" {
+			if err := plan.ValidateFormat(field.Format); err != nil {
+				l.report(fieldPath+".format", "invalid format: %v", err)
+			}
+		}
+
+		if _, _, err := field.ResolveSeparators(); err != nil {
+			l.report(fieldPath+separatorsPath(field), "invalid number separators: %v", err)
+		}
+
+		if field.Slice != "" {
+			if _, _, err := plan.ParseSlice(field.Slice); err != nil {
+				l.report(fieldPath+".slice", "invalid slice: %v", err)
+			}
+		}
+
+		l.fields(fieldPath+".fields", field.Fields)
+	}
+}
+
+// separatorsPath returns the path of the setting defining the number separators of a field.
+func separatorsPath(field *plan.Field) string {
+	switch {
+	case field.Locale != "" && field.DecimalSeparator == "" && field.GroupSeparator == "":
+		return ".locale"
+	case field.GroupSeparator != "":
+		return ".group_separator"
+	default:
+		return ".decimal_separator"
+	}
+}
+
+// replaceGroup matches the groups referenced by a replace template e.g "$1", "${2}" or "${name}".
+var replaceGroup = regexp.MustCompile(`\$(\w+)|\$\{(\w+)\}`)
+
+func (l *linter) regex(path string, field *plan.Field) {
+	re, err := regexp.Compile(field.Regex)
+	if err != nil {
+		l.report(path+".regex", "invalid regex: %v", err)
+
+		return
+	}
+
+	named := false
+	for _, name := range re.SubexpNames() {
+		named = named || name != ""
+	}
+
+	if field.Replace != "" {
+		for _, m := range replaceGroup.FindAllStringSubmatch(field.Replace, -1) {
+			ref := m[1] + m[2]
+
+			if n, err := strconv.Atoi(ref); err == nil {
+				if n > re.NumSubexp() {
+					l.report(path+".replace", "references group %d but the regex has %d", n, re.NumSubexp())
+				}
+
+				continue
+			}
+
+			if re.SubexpIndex(ref) < 0 {
+				l.report(path+".replace", "references unknown group %q", ref)
+			}
+		}
+
+		return
+	}
+
+	if !named && re.NumSubexp() > 1 {
+		l.report(
+			path+".regex",
+			"regex has %d capture groups but only the first is extracted, use (?:...), named groups or replace",
+			re.NumSubexp(),
+		)
+	}
+}
+
+func (l *linter) steps(steps []plan.Step) {
+	for i := range steps {
+		if steps[i].Selector != "" {
+			l.checkSelector(fmt.Sprintf("steps[%d].selector", i), steps[i].Selector)
+		}
+
+		if steps[i].Action == "wait" && steps[i].Selector == "" && steps[i].Duration <= 0 {
+			l.report(fmt.Sprintf("steps[%d]", i), "wait needs a selector or a duration")
+		}
+
+		if steps[i].Action == "eval" && steps[i].Value != "" {
+			if _, err := goja.Compile("step", steps[i].Value, false); err != nil {
+				l.report(fmt.Sprintf("steps[%d].value", i), "invalid script: %v", err)
+			}
+		}
+	}
+}
+
+func (l *linter) checkSelector(path, selector string) {
+	if err := l.selector(selector); err != nil {
+		l.report(path, "invalid selector: %v", err)
+	}
+}
+
+func (l *linter) transformer(path string) {
+	if path == "" {
+		return
+	}
+
+	src, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		l.report("transformer", "failed to read transformer: %v", err)
+
+		return
+	}
+
+	if _, err := goja.Compile(path, string(src), false); err != nil {
+		l.report("transformer", "invalid transformer: %v", err)
+	}
+}
+
+func (l *linter) notify(n *plan.Notify) {
+	if n == nil {
+		return
+	}
+
+	if n.Condition != "" {
+		if _, err := goja.Compile("condition", n.Condition, false); err != nil {
+			l.report("notify.condition", "invalid condition: %v", err)
+		}
+	}
+
+	if n.Template != "" {
+		if _, err := output.ParseTemplate(n.Template); err != nil {
+			l.report("notify.template", "invalid template: %v", err)
+		}
+	}
+}
+
+// cssSelector checks the syntax of a CSS selector.
+// XPath expressions, also accepted by the website harvester, are not checked.
+func cssSelector(selector string) error {
+	if strings.HasPrefix(selector, "/") || strings.HasPrefix(selector, "./") || strings.HasPrefix(selector, "(") {
+		return nil
+	}
+
+	_, err := cascadia.Compile(selector)
+
+	return err
+}
+
+// jmespathSelector checks the syntax of a JMESPath expression.
+func jmespathSelector(selector string) error {
+	_, err := jmespath.Compile(selector)
+
+	return err
+}
+
+// yamlName returns the YAML key of a struct field, as decoded by yaml.v2.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+
+	return name
+}
+
+// validationMessage describes a validation failure.
+func validationMessage(fe validator.FieldError) string {
+	param := fe.Param()
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		field, value, _ := strings.Cut(param, " ")
+
+		return fmt.Sprintf("is required when %s is %s", strings.ToLower(field), value)
+	case "required_without":
+		return fmt.Sprintf("is required without %s", strings.ToLower(param))
+	case "excluded_with":
+		return fmt.Sprintf("cannot be used with %s", strings.ToLower(param))
+	case "excluded_without":
+		return fmt.Sprintf("requires %s", strings.ToLower(param))
+	case "oneof":
+		return fmt.Sprintf("must be one of %s, got %q", strings.ReplaceAll(param, " ", ", "), fe.Value())
+	case "url":
+		return fmt.Sprintf("must be a URL, got %q", fe.Value())
+	case "alpha":
+		return fmt.Sprintf("must contain letters only, got %q", fe.Value())
+	case "len":
+		return fmt.Sprintf("must be %s characters long", param)
+	case "min":
+		return fmt.Sprintf("must be at least %s", param)
+	case "contains":
+		return fmt.Sprintf("must contain %q", param)
+	default:
+		return fmt.Sprintf("failed the %q check", fe.Tag())
+	}
+}
+
+// line returns the line of the value at a path e.g "fields[2].regex",
+// falling back to the closest parent when the value is absent.
+func line(root *yaml.Node, path string) int {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	ln := node.Line
+
+	for _, seg := range segments(path) {
+		var next *yaml.Node
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					ln = node.Content[i].Line
+					next = node.Content[i+1]
+
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(seg); err == nil && i < len(node.Content) {
+				next = node.Content[i]
+				ln = next.Line
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		node = next
+	}
+
+	return ln
+}
+
+// segments splits a path e.g "fields[2].regex" into "fields", "2" and "regex".
+func segments(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+}
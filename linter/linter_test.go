@@ -0,0 +1,104 @@
+package linter_test
+
+import (
+	"github.com/mgjules/harvit/linter"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Linter", func() {
+	It("should find nothing wrong with a valid plan", func() {
+		problems, err := linter.LintFile("testdata/valid.yml")
+		Expect(err).To(BeNil())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("should find every problem of an invalid plan with its line", func() {
+		problems, err := linter.LintFile("testdata/invalid.yml")
+		Expect(err).To(BeNil())
+
+		located := make([]string, 0, len(problems))
+		for i := range problems {
+			Expect(problems[i].Message).NotTo(BeEmpty())
+			located = append(located, problems[i].Path)
+			Expect(problems[i].Line).To(BeNumerically(">", 0))
+		}
+
+		Expect(located).To(Equal([]string{
+			"source",
+			"fields[0].selector",
+			"fields[1].name",
+			"fields[1].slice",
+			"fields[2].regex",
+			"fields[3].regex",
+			"fields[4].format",
+			"fields[4].timezone",
+			"fields[5].replace",
+			"fields[6].fields",
+			"transformer",
+			"notify.condition",
+			"notify.template",
+			"schedule",
+		}))
+
+		Expect(problems[0].Line).To(Equal(1))
+		Expect(problems[3].Line).To(Equal(8))
+		Expect(problems[4].Line).To(Equal(12))
+		Expect(problems[13].Line).To(Equal(33))
+	})
+
+	It("should check api selectors as JMESPath expressions", func() {
+		problems := linter.Lint([]byte(`
+source: https://api.example.com
+type: api
+fields:
+  - name: titles
+    selector: "jobs[].title"
+  - name: salaries
+    selector: "jobs[.salary"
+`))
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Path).To(Equal("fields[1].selector"))
+		Expect(problems[0].Line).To(Equal(8))
+	})
+
+	It("should check the number separators", func() {
+		problems := linter.Lint([]byte(`
+source: https://example.com
+fields:
+  - name: price
+    type: decimal
+    selector: .price
+    locale: xx
+  - name: total
+    type: decimal
+    selector: .total
+    decimal_separator: ","
+    group_separator: ","
+`))
+		Expect(problems).To(HaveLen(2))
+		Expect(problems[0].Path).To(Equal("fields[0].locale"))
+		Expect(problems[0].Line).To(Equal(7))
+		Expect(problems[1].Path).To(Equal("fields[1].group_separator"))
+		Expect(problems[1].Line).To(Equal(12))
+	})
+
+	It("should check that wait steps wait for something", func() {
+		problems := linter.Lint([]byte(`
+source: https://example.com
+type: website
+steps:
+  - action: wait
+    selector: "#app"
+  - action: wait
+    duration: 2s
+  - action: wait
+fields:
+  - name: title
+    selector: h1
+`))
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Path).To(Equal("steps[2]"))
+		Expect(problems[0].Line).To(Equal(9))
+	})
+})
@@ -0,0 +1 @@
+data['title'] = data['title'].toUpperCase();
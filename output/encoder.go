@@ -0,0 +1,205 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/mgjules/harvit/json"
+	"gopkg.in/yaml.v2"
+)
+
+// encoder encodes a result to a writer.
+type encoder func(io.Writer, any) error
+
+var encoders = map[string]encoder{
+	FormatJSON:       encodeJSON,
+	FormatPrettyJSON: encodePrettyJSON,
+	FormatNDJSON:     encodeNDJSON,
+	FormatCSV:        encodeCSV,
+	FormatYAML:       encodeYAML,
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(marshaled))
+
+	return err
+}
+
+func encodePrettyJSON(w io.Writer, v any) error {
+	marshaled, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(marshaled))
+
+	return err
+}
+
+// encodeNDJSON writes each item of a list result on its own line.
+func encodeNDJSON(w io.Writer, v any) error {
+	normalized, err := json.Normalize(v)
+	if err != nil {
+		return err
+	}
+
+	list, ok := normalized.([]any)
+	if !ok {
+		return encodeJSON(w, normalized)
+	}
+
+	for i := range list {
+		if err := encodeJSON(w, list[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeCSV writes each record of a list result as a row, after a header.
+// A single record is written as a single row and nested values as JSON.
+// The columns are the keys of the records in alphabetical order.
+func encodeCSV(w io.Writer, v any) error {
+	normalized, err := json.Normalize(v)
+	if err != nil {
+		return err
+	}
+
+	records := toRecords(normalized)
+
+	var header []string
+	seen := make(map[string]bool)
+	for i := range records {
+		for k := range records[i] {
+			if !seen[k] {
+				seen[k] = true
+				header = append(header, k)
+			}
+		}
+	}
+	// Results are maps, so the columns are sorted to stay the same from one run to the next.
+	sort.Strings(header)
+
+	return writeCSV(w, records, header, true)
+}
+
+// csvRowsEncoder returns an encoder writing the rows of encodeCSV in the columns of an existing header,
+// to append them to an existing file. Records with a column missing from the header are rejected.
+func csvRowsEncoder(header []string) encoder {
+	return func(w io.Writer, v any) error {
+		normalized, err := json.Normalize(v)
+		if err != nil {
+			return err
+		}
+
+		records := toRecords(normalized)
+
+		columns := make(map[string]bool, len(header))
+		for _, k := range header {
+			columns[k] = true
+		}
+
+		for i := range records {
+			for k := range records[i] {
+				if !columns[k] {
+					return fmt.Errorf("failed to append csv row: column %q is not in the header %v", k, header)
+				}
+			}
+		}
+
+		return writeCSV(w, records, header, false)
+	}
+}
+
+func writeCSV(w io.Writer, records []map[string]any, header []string, withHeader bool) error {
+	var err error
+
+	cw := csv.NewWriter(w)
+	if withHeader {
+		if err := cw.Write(header); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+	}
+
+	for i := range records {
+		row := make([]string, len(header))
+		for j, k := range header {
+			if row[j], err = cell(records[i][k]); err != nil {
+				return err
+			}
+		}
+
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func encodeYAML(w io.Writer, v any) error {
+	normalized, err := json.Normalize(v)
+	if err != nil {
+		return err
+	}
+
+	marshaled, err := yaml.Marshal(normalized)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	_, err = w.Write(marshaled)
+
+	return err
+}
+
+func toRecords(v any) []map[string]any {
+	switch t := v.(type) {
+	case map[string]any:
+		return []map[string]any{t}
+	case []any:
+		records := make([]map[string]any, 0, len(t))
+		for i := range t {
+			if record, ok := t[i].(map[string]any); ok {
+				records = append(records, record)
+			} else {
+				records = append(records, map[string]any{"value": t[i]})
+			}
+		}
+
+		return records
+	default:
+		return []map[string]any{{"value": t}}
+	}
+}
+
+func cell(v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	default:
+		marshaled, err := json.Marshal(t)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal cell: %w", err)
+		}
+
+		return string(marshaled), nil
+	}
+}
@@ -0,0 +1,122 @@
+package output
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Output formats.
+const (
+	FormatJSON       = "json"
+	FormatPrettyJSON = "pretty"
+	FormatNDJSON     = "ndjson"
+	FormatCSV        = "csv"
+	FormatYAML       = "yaml"
+)
+
+// Stdout is the path of the standard output.
+const Stdout = "-"
+
+// New returns a new Sink writing results in the given format to a path.
+// An empty path or Stdout writes to the standard output.
+func New(format, path string) (Sink, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+
+	enc, found := encoders[format]
+	if !found {
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+
+	if path == "" || path == Stdout {
+		return &Writer{w: os.Stdout, encode: enc}, nil
+	}
+
+	f, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	return &Writer{w: f, c: f, encode: enc}, nil
+}
+
+// NewAppend returns a new Sink like New, except that ndjson and csv results are appended to the file at path.
+// The csv header is only written to an empty file, the rows appended afterwards following its columns.
+// Other formats hold a single document and overwrite the file.
+func NewAppend(format, path string) (Sink, error) {
+	if (format != FormatNDJSON && format != FormatCSV) || path == "" || path == Stdout {
+		return New(format, path)
+	}
+
+	f, err := os.OpenFile(filepath.Clean(path), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	enc := encoders[format]
+	if format == FormatCSV && info.Size() > 0 {
+		// Reads start at the beginning of the file, writes still append.
+		header, err := csv.NewReader(f).Read()
+		if err != nil {
+			f.Close()
+
+			return nil, fmt.Errorf("failed to read csv header: %w", err)
+		}
+
+		enc = csvRowsEncoder(header)
+	}
+
+	return &Writer{w: f, c: f, encode: enc}, nil
+}
+
+// Sink writes results somewhere.
+type Sink interface {
+	Write(context.Context, any) error
+	Close() error
+}
+
+// Writer is a sink that encodes results to an io.Writer.
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	c      io.Closer
+	encode encoder
+}
+
+// Write encodes a result to the underlying writer.
+func (w *Writer) Write(_ context.Context, v any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.encode(w.w, v); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying writer, if needed.
+func (w *Writer) Close() error {
+	if w.c == nil {
+		return nil
+	}
+
+	if err := w.c.Close(); err != nil {
+		return fmt.Errorf("failed to close output: %w", err)
+	}
+
+	return nil
+}
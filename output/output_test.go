@@ -0,0 +1,121 @@
+package output_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgjules/harvit/output"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output", func() {
+	records := []map[string]any{
+		{"title": "Gopher", "salary": 100, "tags": []string{"go"}},
+		{"title": "Rustacean", "remote": true},
+	}
+
+	DescribeTable("should write the records",
+		func(format, expected string) {
+			path := filepath.Join(GinkgoT().TempDir(), "out")
+
+			sink, err := output.New(format, path)
+			Expect(err).To(BeNil())
+
+			Expect(sink.Write(context.Background(), records)).To(Succeed())
+			Expect(sink.Close()).To(Succeed())
+
+			b, err := os.ReadFile(path)
+			Expect(err).To(BeNil())
+
+			Expect(string(b)).To(Equal(expected))
+		},
+		Entry("as json", output.FormatJSON,
+			`[{"salary":100,"tags":["go"],"title":"Gopher"},{"remote":true,"title":"Rustacean"}]`+"\n",
+		),
+		Entry("as ndjson", output.FormatNDJSON,
+			`{"salary":100,"tags":["go"],"title":"Gopher"}`+"\n"+
+				`{"remote":true,"title":"Rustacean"}`+"\n",
+		),
+		Entry("as csv", output.FormatCSV,
+			"remote,salary,tags,title\n"+
+				`,100,"[""go""]",Gopher`+"\n"+
+				"true,,,Rustacean\n",
+		),
+		Entry("as yaml", output.FormatYAML,
+			"- salary: 100\n  tags:\n  - go\n  title: Gopher\n- remote: true\n  title: Rustacean\n",
+		),
+	)
+
+	It("should reject an unknown format", func() {
+		_, err := output.New("xml", "")
+		Expect(err).NotTo(BeNil())
+	})
+
+	DescribeTable("should append the records",
+		func(format, expected string) {
+			path := filepath.Join(GinkgoT().TempDir(), "out")
+
+			for i := 0; i < 2; i++ {
+				sink, err := output.NewAppend(format, path)
+				Expect(err).To(BeNil())
+
+				Expect(sink.Write(context.Background(), records)).To(Succeed())
+				Expect(sink.Close()).To(Succeed())
+			}
+
+			b, err := os.ReadFile(path)
+			Expect(err).To(BeNil())
+
+			Expect(string(b)).To(Equal(expected))
+		},
+		Entry("as ndjson", output.FormatNDJSON, strings.Repeat(
+			`{"salary":100,"tags":["go"],"title":"Gopher"}`+"\n"+
+				`{"remote":true,"title":"Rustacean"}`+"\n", 2),
+		),
+		Entry("as csv", output.FormatCSV,
+			"remote,salary,tags,title\n"+strings.Repeat(
+				`,100,"[""go""]",Gopher`+"\n"+
+					"true,,,Rustacean\n", 2),
+		),
+		Entry("overwriting json", output.FormatJSON,
+			`[{"salary":100,"tags":["go"],"title":"Gopher"},{"remote":true,"title":"Rustacean"}]`+"\n",
+		),
+	)
+
+	It("should append csv rows in the columns of the existing header", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "out.csv")
+
+		for _, v := range []any{
+			records,
+			[]map[string]any{{"title": "Pythonista", "salary": 300}},
+			map[string]any{"tags": []string{"zig"}},
+		} {
+			sink, err := output.NewAppend(output.FormatCSV, path)
+			Expect(err).To(BeNil())
+
+			Expect(sink.Write(context.Background(), v)).To(Succeed())
+			Expect(sink.Close()).To(Succeed())
+		}
+
+		sink, err := output.NewAppend(output.FormatCSV, path)
+		Expect(err).To(BeNil())
+
+		err = sink.Write(context.Background(), map[string]any{"title": "Gopher", "city": "Port Louis"})
+		Expect(err).To(MatchError(ContainSubstring(`column "city" is not in the header`)))
+		Expect(sink.Close()).To(Succeed())
+
+		b, err := os.ReadFile(path)
+		Expect(err).To(BeNil())
+
+		Expect(string(b)).To(Equal(
+			"remote,salary,tags,title\n" +
+				`,100,"[""go""]",Gopher` + "\n" +
+				"true,,,Rustacean\n" +
+				",300,,Pythonista\n" +
+				`,,"[""zig""]",` + "\n",
+		))
+	})
+})
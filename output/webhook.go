@@ -0,0 +1,171 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mgjules/harvit/json"
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/plan"
+	"github.com/mgjules/harvit/transformer"
+)
+
+// Webhook is a sink that sends results to a webhook.
+type Webhook struct {
+	notify   plan.Notify
+	template *template.Template
+	client   *http.Client
+}
+
+// NewWebhook returns a new Webhook sink for a notify.
+func NewWebhook(n plan.Notify) (*Webhook, error) {
+	w := &Webhook{
+		notify: n,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if n.Template != "" {
+		tmpl, err := ParseTemplate(n.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse notify template: %w", err)
+		}
+
+		w.template = tmpl
+	}
+
+	return w, nil
+}
+
+// ParseTemplate parses a notification template.
+// Besides the builtin functions, "json" marshals a value to JSON.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("notify").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			marshaled, err := json.Marshal(v)
+
+			return string(marshaled), err
+		},
+	}).Parse(text)
+}
+
+// Write sends a result to the webhook when it matches the condition.
+// Failed requests are retried with an exponential backoff.
+func (w *Webhook) Write(ctx context.Context, v any) error {
+	return w.WriteIf(ctx, v, v)
+}
+
+// WriteIf sends v to the webhook when subject matches the condition,
+// e.g the changes of a result only when the result itself matches.
+func (w *Webhook) WriteIf(ctx context.Context, subject, v any) error {
+	normalized, err := json.Normalize(v)
+	if err != nil {
+		return err
+	}
+
+	if w.notify.Condition != "" {
+		normalizedSubject, err := json.Normalize(subject)
+		if err != nil {
+			return err
+		}
+
+		ok, err := transformer.Match(ctx, w.notify.Condition, normalizedSubject)
+		if err != nil {
+			return fmt.Errorf("failed to match notify condition: %w", err)
+		}
+
+		if !ok {
+			logger.Log.Debugw("skipping notification, condition not met", "condition", w.notify.Condition)
+
+			return nil
+		}
+	}
+
+	body, contentType, err := w.body(normalized)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.notify.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.send(ctx, body, contentType)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= w.notify.Retries {
+			return err
+		}
+
+		logger.Log.Warnw("failed to notify, retrying", "url", w.notify.URL, "backoff", backoff, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to notify: %w", ctx.Err())
+		}
+
+		backoff *= 2
+	}
+}
+
+// Close does nothing as the webhook holds no resources.
+func (w *Webhook) Close() error {
+	return nil
+}
+
+// body returns the rendered template or the result as JSON.
+func (w *Webhook) body(v any) ([]byte, string, error) {
+	if w.template == nil {
+		marshaled, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal notification: %w", err)
+		}
+
+		return marshaled, "application/json", nil
+	}
+
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, v); err != nil {
+		return nil, "", fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	return buf.Bytes(), "text/plain; charset=utf-8", nil
+}
+
+// send sends a single request and reports whether it is worth retrying on failure.
+func (w *Webhook) send(ctx context.Context, body []byte, contentType string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, w.notify.Method, w.notify.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create notification request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range w.notify.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to notify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so that the connection can be reused.
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		logger.Log.Debugw("failed to read notification response", "error", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+
+		return retry, fmt.Errorf("failed to notify: unexpected status %s", strings.TrimSpace(resp.Status))
+	}
+
+	return false, nil
+}
@@ -0,0 +1,134 @@
+package output_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/mgjules/harvit/logger"
+	"github.com/mgjules/harvit/output"
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var (
+		ts       *httptest.Server
+		mu       sync.Mutex
+		bodies   []string
+		failures int
+	)
+
+	BeforeEach(func() {
+		_, err := logger.New(false)
+		Expect(err).To(BeNil())
+
+		bodies = nil
+		failures = 0
+
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, r.Header.Get("X-Token")+" "+string(b))
+		}))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	data := map[string]any{"title": "Gopher", "price": 80}
+
+	It("should post the result as json", func() {
+		webhook, err := output.NewWebhook(plan.Notify{
+			URL:     ts.URL,
+			Method:  "POST",
+			Headers: map[string]string{"X-Token": "secret"},
+		})
+		Expect(err).To(BeNil())
+
+		Expect(webhook.Write(context.Background(), data)).To(Succeed())
+		Expect(bodies).To(Equal([]string{`secret {"price":80,"title":"Gopher"}`}))
+	})
+
+	It("should post a templated message", func() {
+		webhook, err := output.NewWebhook(plan.Notify{
+			URL:      ts.URL,
+			Method:   "POST",
+			Template: "{{ .title }} is now {{ .price }}",
+		})
+		Expect(err).To(BeNil())
+
+		Expect(webhook.Write(context.Background(), data)).To(Succeed())
+		Expect(bodies).To(Equal([]string{" Gopher is now 80"}))
+	})
+
+	It("should only notify when the condition is met", func() {
+		webhook, err := output.NewWebhook(plan.Notify{
+			URL:       ts.URL,
+			Method:    "POST",
+			Condition: "price < 50",
+		})
+		Expect(err).To(BeNil())
+
+		Expect(webhook.Write(context.Background(), data)).To(Succeed())
+		Expect(bodies).To(BeEmpty())
+	})
+
+	It("should match the condition against the subject", func() {
+		webhook, err := output.NewWebhook(plan.Notify{
+			URL:       ts.URL,
+			Method:    "POST",
+			Condition: "price < 100",
+		})
+		Expect(err).To(BeNil())
+
+		changes := []map[string]any{{"path": "price", "before": 120, "after": 80}}
+		Expect(webhook.WriteIf(context.Background(), data, changes)).To(Succeed())
+		Expect(webhook.WriteIf(context.Background(), map[string]any{"price": 120}, changes)).To(Succeed())
+		Expect(bodies).To(Equal([]string{` [{"after":80,"before":120,"path":"price"}]`}))
+	})
+
+	It("should retry failed notifications", func() {
+		failures = 2
+
+		webhook, err := output.NewWebhook(plan.Notify{
+			URL:     ts.URL,
+			Method:  "POST",
+			Retries: 2,
+			Backoff: time.Millisecond,
+		})
+		Expect(err).To(BeNil())
+
+		Expect(webhook.Write(context.Background(), data)).To(Succeed())
+		Expect(bodies).To(HaveLen(1))
+	})
+
+	It("should give up after the retries", func() {
+		failures = 2
+
+		webhook, err := output.NewWebhook(plan.Notify{
+			URL:     ts.URL,
+			Method:  "POST",
+			Retries: 1,
+			Backoff: time.Millisecond,
+		})
+		Expect(err).To(BeNil())
+
+		Expect(webhook.Write(context.Background(), data)).NotTo(Succeed())
+		Expect(bodies).To(BeEmpty())
+	})
+})
@@ -0,0 +1,40 @@
+package plan
+
+import (
+	"strings"
+)
+
+// localeSeparators holds the decimal and group separators of numbers by locale,
+// either a language e.g "fr" or a language and a region e.g "de-ch".
+var localeSeparators = map[string][2]rune{
+	"en": {'.', ','}, "ja": {'.', ','}, "zh": {'.', ','}, "ko": {'.', ','}, "he": {'.', ','},
+	"th": {'.', ','}, "hi": {'.', ','}, "ms": {'.', ','}, "fil": {'.', ','}, "ga": {'.', ','},
+	"mt": {'.', ','}, "sw": {'.', ','}, "es-mx": {'.', ','}, "es-us": {'.', ','},
+
+	"de": {',', '.'}, "es": {',', '.'}, "it": {',', '.'}, "nl": {',', '.'}, "pt": {',', '.'},
+	"id": {',', '.'}, "tr": {',', '.'}, "da": {',', '.'}, "el": {',', '.'}, "ro": {',', '.'},
+	"hr": {',', '.'}, "sl": {',', '.'}, "sr": {',', '.'}, "vi": {',', '.'}, "ca": {',', '.'},
+	"is": {',', '.'},
+
+	"fr": {',', ' '}, "ru": {',', ' '}, "pl": {',', ' '}, "cs": {',', ' '}, "sk": {',', ' '},
+	"uk": {',', ' '}, "fi": {',', ' '}, "sv": {',', ' '}, "nb": {',', ' '}, "nn": {',', ' '},
+	"no": {',', ' '}, "hu": {',', ' '}, "bg": {',', ' '}, "lt": {',', ' '}, "lv": {',', ' '},
+	"et": {',', ' '}, "be": {',', ' '}, "kk": {',', ' '}, "pt-pt": {',', ' '}, "de-at": {',', ' '},
+	"en-za": {',', ' '},
+
+	"de-ch": {'.', '\''}, "fr-ch": {'.', '\''}, "it-ch": {'.', '\''}, "de-li": {'.', '\''},
+}
+
+// LocaleSeparators returns the decimal and group separators of numbers in a locale e.g "fr", "de-CH" or "pt_PT".
+// A locale with an unknown region falls back to its language.
+func LocaleSeparators(locale string) (rune, rune, bool) {
+	normalized := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+
+	seps, found := localeSeparators[normalized]
+	if !found {
+		lang, _, _ := strings.Cut(normalized, "-")
+		seps, found = localeSeparators[lang]
+	}
+
+	return seps[0], seps[1], found
+}
@@ -1,22 +1,50 @@
 package plan
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/golang-module/carbon/v2"
+	"github.com/samber/lo"
 	"gopkg.in/yaml.v2"
 )
 
 // Plan defines the parameters for harvesting.
 type Plan struct {
+	// URL of the source, file:// URLs read a saved page or a HAR file.
 	Source     string   `yaml:"source" validate:"required,url"`
-	Type       string   `yaml:"type" validate:"required,oneof=website"`
+	Type       string   `yaml:"type" validate:"required,oneof=website html api"`
 	UserAgents []string `yaml:"user_agents"`
-	Fields     []Field  `yaml:",flow" validate:"required,dive"`
+	// Maximum time to load the source and perform the steps e.g "30s".
+	Timeout time.Duration `yaml:"timeout" validate:"min=0"`
+	// HTTP request options used by the html and api harvesters.
+	Request Request `yaml:"request"`
+	// HAR file replaying the responses to the source and its links instead of fetching them.
+	Archive string `yaml:"archive"`
+	// Browser steps performed by the website harvester before harvesting the fields.
+	Steps  []Step  `yaml:"steps" validate:"dive"`
+	Fields []Field `yaml:",flow" validate:"required,dive"`
+	// Subsequent pages of the source to harvest with the same fields.
+	Pagination *Pagination `yaml:"pagination" validate:"omitempty"`
+	// Links to follow from each page and harvest with their own fields.
+	Follow *Follow `yaml:"follow" validate:"omitempty"`
 	// Location of the transformer file.
 	Transformer string `yaml:"transformer"`
+	// Where and how the result is written.
+	Output Output `yaml:"output"`
+	// Cron expression used by the schedule command e.g "*/15 * * * *" or "@every 1h".
+	Schedule string `yaml:"schedule"`
+	// Webhook notified with the result.
+	Notify *Notify `yaml:"notify" validate:"omitempty"`
 }
 
 // SetDefaults sets the default values for the plan.
@@ -25,23 +53,240 @@ func (p *Plan) SetDefaults() {
 		p.Type = "website"
 	}
 
+	p.Request.SetDefaults()
+
+	for i := range p.Steps {
+		p.Steps[i].SetDefaults()
+	}
+
 	for i := range p.Fields {
 		p.Fields[i].SetDefaults()
 	}
+
+	if p.Pagination != nil {
+		p.Pagination.SetDefaults()
+	}
+
+	if p.Follow != nil {
+		p.Follow.SetDefaults()
+	}
+
+	if p.Notify != nil {
+		p.Notify.SetDefaults()
+	}
+}
+
+// ResultFields returns the fields describing the harvested data,
+// including the list field produced by Follow.
+func (p *Plan) ResultFields() []Field {
+	if p.Follow == nil {
+		return p.Fields
+	}
+
+	fields := make([]Field, 0, len(p.Fields)+1)
+	fields = append(fields, p.Fields...)
+	fields = append(fields, Field{
+		Name:     p.Follow.Name,
+		Type:     "list",
+		Selector: p.Follow.Selector,
+		Fields:   p.Follow.Fields,
+	})
+
+	return fields
+}
+
+// Request defines how a source is requested over HTTP.
+type Request struct {
+	Method  string            `yaml:"method" validate:"oneof=GET POST PUT PATCH DELETE"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+// SetDefaults sets the default values for the request.
+func (r *Request) SetDefaults() {
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+}
+
+// Output defines where and how the result is written.
+type Output struct {
+	Format string `yaml:"format" validate:"omitempty,oneof=json pretty ndjson csv yaml"`
+	// File to write the result to, standard output when empty.
+	Path string `yaml:"path"`
+}
+
+// Notify defines a webhook notified with the result.
+type Notify struct {
+	URL     string            `yaml:"url" validate:"required,url"`
+	Method  string            `yaml:"method" validate:"oneof=POST PUT PATCH"`
+	Headers map[string]string `yaml:"headers"`
+	// JavaScript expression deciding whether to notify e.g "price < 100".
+	// The fields of the result are available as variables, the whole result as "data".
+	Condition string `yaml:"condition"`
+	// Go template of the body, the result as JSON when empty.
+	// See: https://pkg.go.dev/text/template
+	Template string `yaml:"template"`
+	// Number of retries after a failed notification.
+	Retries int `yaml:"retries" validate:"min=0"`
+	// Delay before the first retry, doubled after each retry e.g "1s".
+	Backoff time.Duration `yaml:"backoff" validate:"min=0"`
+}
+
+// SetDefaults sets the default values for the notify.
+func (n *Notify) SetDefaults() {
+	if n.Method == "" {
+		n.Method = "POST"
+	}
+
+	if n.Backoff == 0 {
+		n.Backoff = time.Second
+	}
+}
+
+// Step is a browser action performed before harvesting.
+type Step struct {
+	Action string `yaml:"action" validate:"required,oneof=click fill select scroll wait eval"`
+	// CSS Selector of the target element.
+	// Required by click, fill and select; optional for wait.
+	Selector string `yaml:"selector" validate:"required_if=Action click,required_if=Action fill,required_if=Action select"`
+	// Text to fill, option to select or script to evaluate.
+	Value string `yaml:"value" validate:"required_if=Action eval"`
+	// Whether wait waits for the element to be visible instead of ready.
+	Visible bool `yaml:"visible"`
+	// Time to wait, or to pause after each scroll e.g "2s".
+	Duration time.Duration `yaml:"duration"`
+	// Number of times scroll scrolls to the bottom of the page.
+	Times int `yaml:"times" validate:"min=0"`
+}
+
+// SetDefaults sets the default values for the step.
+func (s *Step) SetDefaults() {
+	if s.Action != "scroll" {
+		return
+	}
+
+	if s.Times == 0 {
+		s.Times = 1
+	}
+
+	if s.Duration == 0 {
+		s.Duration = time.Second
+	}
+}
+
+// Pagination defines how subsequent pages of the source are reached.
+type Pagination struct {
+	// Selector of the link to the next page.
+	Next string `yaml:"next" validate:"required_without=URL,excluded_with=URL"`
+	// URL template of the next pages where "{page}" is replaced by the page number.
+	URL string `yaml:"url" validate:"omitempty,contains={page}"`
+	// Page number of the first page after the source when using URL, 2 by default.
+	// Zero-based templates set it to 1.
+	Start *int `yaml:"start" validate:"omitempty,min=0"`
+	// Maximum number of pages to harvest, including the source.
+	MaxPages int `yaml:"max_pages" validate:"min=1"`
+}
+
+// SetDefaults sets the default values for the pagination.
+func (p *Pagination) SetDefaults() {
+	if p.MaxPages == 0 {
+		p.MaxPages = 10
+	}
+}
+
+// FirstPage returns the page number of the first page after the source, 2 if Start is not set.
+func (p *Pagination) FirstPage() int {
+	if p.Start == nil {
+		return 2
+	}
+
+	return *p.Start
+}
+
+// Follow defines links whose targets are harvested with their own fields.
+type Follow struct {
+	// Name of the list holding the harvested targets.
+	Name string `yaml:"name" validate:"required,alpha"`
+	// Selector of the links to follow.
+	Selector string  `yaml:"selector" validate:"required"`
+	Fields   []Field `yaml:"fields" validate:"required,dive"`
+	// Maximum number of links to follow per page, 0 means no limit.
+	MaxLinks int `yaml:"max_links" validate:"min=0"`
+}
+
+// SetDefaults sets the default values for the follow.
+func (f *Follow) SetDefaults() {
+	for i := range f.Fields {
+		f.Fields[i].SetDefaults()
+	}
 }
 
 // Field is a single piece of data.
 type Field struct {
 	Name string `yaml:"name" validate:"required,alpha"`
-	Type string `yaml:"type" validate:"required,oneof=raw text number decimal datetime"`
-	// CSS Selector.
-	Selector string `yaml:"selector" validate:"required"`
+	Type string `yaml:"type" validate:"required,oneof=raw text number decimal datetime object list"`
+	// CSS Selector or JMESPath expression for the api harvester.
+	Selector string `yaml:"selector" validate:"required_without=Selectors,excluded_with=Selectors"`
+	// Fallback selectors tried in order until one matches, instead of Selector.
+	Selectors []string `yaml:"selectors" validate:"omitempty,dive,required"`
+	// Attribute of the matched node to extract e.g "href".
+	Attribute string `yaml:"attribute" validate:"excluded_with=Property"`
+	// DOM property of the matched node to extract e.g "value" or "innerText".
+	Property string `yaml:"property"`
+	// Text extraction mode: "first" reads the first text node only,
+	// "full" reads the whole text content with whitespace collapsed.
+	Extract string `yaml:"extract" validate:"required,oneof=first full"`
 	// Regex to extract data from the selector.
+	// The first capture group is extracted, or the whole match without any group.
+	// Named groups e.g "(?P<amount>\d+) (?P<currency>\w+)" extract an object instead.
 	Regex string `yaml:"regex"`
+	// Whether the regex extracts every match into a list instead of the first one.
+	RegexAll bool `yaml:"regex_all" validate:"excluded_without=Regex"`
+	// Template built from the regex groups instead e.g "$2-$1" or "${year}-${month}".
+	Replace string `yaml:"replace" validate:"excluded_without=Regex"`
+	// What a value the regex does not match becomes: "null", "keep" the original value,
+	// or "error" reporting it as a conformance error.
+	NoMatch string `yaml:"no_match" validate:"required,oneof=null keep error"`
 	// See: https://github.com/golang-module/carbon#format-sign-table
 	Format string `yaml:"format"`
 	// TZ Database name e.g "Indian/Mauritius"
 	Timezone string `yaml:"timezone"`
+	// Locale of the numbers e.g "fr" or "de-CH", setting their decimal and group separators.
+	Locale string `yaml:"locale"`
+	// Decimal separator of the numbers, overriding the one of the locale. Defaults to ".".
+	DecimalSeparator string `yaml:"decimal_separator" validate:"omitempty,len=1"`
+	// Group separator of the numbers, overriding the one of the locale.
+	// Defaults to "," or to "." when the decimal separator is ",".
+	GroupSeparator string `yaml:"group_separator" validate:"omitempty,len=1"`
+	// Whether numbers may end with a magnitude suffix e.g "1.2k", "3 bn" or "1,5 Mio".
+	Magnitudes bool `yaml:"magnitudes"`
+	// Harvest the first node matched by Selector only.
+	First bool `yaml:"first" validate:"excluded_with=Last Index Slice"`
+	// Harvest the last node matched by Selector only.
+	Last bool `yaml:"last" validate:"excluded_with=Index Slice"`
+	// Harvest the node at this index only, negative indexes count from the last node e.g -2.
+	Index *int `yaml:"index" validate:"excluded_with=Slice"`
+	// Harvest a range of the nodes, Python style e.g "2:5", "3:" or ":-1".
+	Slice string `yaml:"slice"`
+	// Maximum number of nodes to harvest, 0 for no limit.
+	Limit int `yaml:"limit" validate:"min=0"`
+	// Whether the field always holds a list of values (true) or a single value (false).
+	// By default, it holds a list only when its selector matches several nodes.
+	// Object and list fields ignore it.
+	Multiple *bool `yaml:"multiple"`
+	// Optional fields do not wait for their selector to match.
+	Optional bool `yaml:"optional"`
+	// Maximum time to wait for the selector to match e.g "10s".
+	Timeout time.Duration `yaml:"timeout" validate:"min=0"`
+	// Child fields of an object or list field, evaluated relative to each node matched by Selector.
+	Fields []Field `yaml:"fields" validate:"required_if=Type object,required_if=Type list,dive"`
+
+	// Compiled by Compile.
+	regexp    *regexp.Regexp
+	location  *time.Location
+	sliceFrom *int
+	sliceTo   *int
 }
 
 // SetDefaults sets the default values for a field.
@@ -49,18 +294,280 @@ func (d *Field) SetDefaults() {
 	if d.Type == "" {
 		d.Type = "text"
 	}
+
+	if d.Extract == "" {
+		d.Extract = "first"
+	}
+
+	if d.NoMatch == "" {
+		d.NoMatch = "error"
+	}
+
+	for i := range d.Fields {
+		d.Fields[i].SetDefaults()
+	}
+}
+
+// Separators returns the decimal and group separators of the numbers of the field.
+// It falls back to "." and "," when they are invalid, which Compile reports.
+func (d *Field) Separators() (rune, rune) {
+	decimal, group, err := d.ResolveSeparators()
+	if err != nil {
+		return '.', ','
+	}
+
+	return decimal, group
+}
+
+// ResolveSeparators returns the decimal and group separators of the numbers of the field:
+// the explicit ones, else the ones of its locale, else "." and ",".
+func (d *Field) ResolveSeparators() (rune, rune, error) {
+	decimal, group := '.', ','
+
+	if d.Locale != "" {
+		var found bool
+		if decimal, group, found = LocaleSeparators(d.Locale); !found {
+			return 0, 0, fmt.Errorf("unknown locale %q", d.Locale)
+		}
+	}
+
+	if d.DecimalSeparator != "" {
+		decimal, _ = utf8.DecodeRuneInString(d.DecimalSeparator)
+		if d.Locale == "" && decimal == ',' {
+			group = '.'
+		}
+	}
+
+	if d.GroupSeparator != "" {
+		group, _ = utf8.DecodeRuneInString(d.GroupSeparator)
+	}
+
+	if decimal == group {
+		return 0, 0, fmt.Errorf("decimal and group separators are both %q", decimal)
+	}
+
+	if unicode.IsDigit(decimal) || unicode.IsDigit(group) {
+		return 0, 0, errors.New("separators cannot be digits")
+	}
+
+	return decimal, group, nil
+}
+
+// AllSelectors returns the selectors of the field in the order they are tried.
+func (d *Field) AllSelectors() []string {
+	if len(d.Selectors) > 0 {
+		return d.Selectors
+	}
+
+	return []string{d.Selector}
+}
+
+// WithSelector returns a copy of the field matching a single selector.
+func (d *Field) WithSelector(selector string) *Field {
+	f := *d
+	f.Selector = selector
+	f.Selectors = nil
+
+	return &f
+}
+
+// Pick returns the bounds [from, to) of the nodes to harvest among n matched nodes,
+// according to First, Last, Index, Slice and Limit.
+func (d *Field) Pick(n int) (int, int) {
+	from, to := 0, n
+
+	sliceFrom, sliceTo := d.Bounds()
+	if sliceFrom != nil {
+		from = sliceBound(*sliceFrom, n)
+	}
+
+	if sliceTo != nil {
+		to = sliceBound(*sliceTo, n)
+	}
+
+	if from >= to {
+		return 0, 0
+	}
+
+	if d.Limit > 0 && to-from > d.Limit {
+		to = from + d.Limit
+	}
+
+	return from, to
+}
+
+// Bounds returns the slice bounds of the nodes to harvest according to First, Last, Index and Slice,
+// before Limit. Negative bounds count from the last node and nil bounds are omitted.
+func (d *Field) Bounds() (*int, *int) {
+	switch {
+	case d.First:
+		return lo.ToPtr(0), lo.ToPtr(1)
+	case d.Last:
+		return lo.ToPtr(-1), nil
+	case d.Index != nil && *d.Index == -1:
+		return lo.ToPtr(-1), nil
+	case d.Index != nil:
+		return lo.ToPtr(*d.Index), lo.ToPtr(*d.Index + 1)
+	default:
+		return d.sliceFrom, d.sliceTo
+	}
+}
+
+// sliceBound resolves a slice bound against n nodes, negative bounds counting from the end.
+func sliceBound(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+
+	return min(max(i, 0), n)
+}
+
+// ParseSlice parses a slice e.g "2:5" into its bounds, nil when omitted.
+func ParseSlice(s string) (*int, *int, error) {
+	rawFrom, rawTo, found := strings.Cut(s, ":")
+	if !found {
+		return nil, nil, fmt.Errorf("missing colon in %q", s)
+	}
+
+	from, err := parseSliceBound(rawFrom)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	to, err := parseSliceBound(rawTo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return from, to, nil
+}
+
+func parseSliceBound(s string) (*int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bound %q: %w", s, err)
+	}
+
+	return &i, nil
+}
+
+// IsMultiple reports whether the field always holds a list of values.
+func (d *Field) IsMultiple() bool {
+	return d.Multiple != nil && *d.Multiple
+}
+
+// IsSingle reports whether the field always holds a single value.
+func (d *Field) IsSingle() bool {
+	return d.Multiple != nil && !*d.Multiple
+}
+
+// Compile compiles the regex, resolves the timezone and checks the format of the field
+// and its child fields. Every problem found is returned, joined.
+func (d *Field) Compile() error {
+	var errs []error
+
+	if d.Regex != "" {
+		re, err := regexp.Compile(d.Regex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to compile regex of field %s: %w", d.Name, err))
+		}
+
+		d.regexp = re
+	}
+
+	if d.Timezone != "" {
+		loc, err := time.LoadLocation(d.Timezone)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load timezone of field %s: %w", d.Name, err))
+		}
+
+		d.location = loc
+	}
+
+	if d.Format != "" {
+		if err := ValidateFormat(d.Format); err != nil {
+			errs = append(errs, fmt.Errorf("invalid format of field %s: %w", d.Name, err))
+		}
+	}
+
+	if _, _, err := d.ResolveSeparators(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid number separators of field %s: %w", d.Name, err))
+	}
+
+	if d.Slice != "" {
+		from, to, err := ParseSlice(d.Slice)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid slice of field %s: %w", d.Name, err))
+		}
+
+		d.sliceFrom, d.sliceTo = from, to
+	}
+
+	for i := range d.Fields {
+		errs = append(errs, d.Fields[i].Compile())
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateFormat checks that a datetime format is usable,
+// i.e that a date formatted with it can be parsed back.
+func ValidateFormat(format string) error {
+	ref := carbon.CreateFromDateTime(2006, 1, 2, 15, 4, 5)
+	if parsed := carbon.ParseByFormat(ref.Format(format), format); parsed.Error != nil {
+		return parsed.Error
+	}
+
+	return nil
+}
+
+// Regexp returns the compiled regex of the field, nil until compiled.
+func (d *Field) Regexp() *regexp.Regexp {
+	return d.regexp
+}
+
+// Location returns the resolved timezone of the field, nil until compiled.
+func (d *Field) Location() *time.Location {
+	return d.location
+}
+
+// Compile compiles the fields of the plan, returning the problems of every field.
+func (p *Plan) Compile() error {
+	errs := make([]error, 0, len(p.Fields))
+	for i := range p.Fields {
+		errs = append(errs, p.Fields[i].Compile())
+	}
+
+	if p.Follow != nil {
+		for i := range p.Follow.Fields {
+			errs = append(errs, p.Follow.Fields[i].Compile())
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 // Load loads a plan from a file.
+// The returned plan is validated and compiled.
 func Load(path string) (*Plan, error) {
 	raw, err := ioutil.ReadFile(filepath.Clean(path))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read plan file: %w", err)
 	}
 
+	return Parse(raw)
+}
+
+// Parse parses, validates and compiles a plan in YAML or JSON format.
+func Parse(raw []byte) (*Plan, error) {
 	var plan Plan
 	if err := yaml.Unmarshal(raw, &plan); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal plan file: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal plan: %w", err)
 	}
 
 	plan.SetDefaults()
@@ -70,5 +577,9 @@ func Load(path string) (*Plan, error) {
 		return nil, fmt.Errorf("failed to validate plan: %w", err)
 	}
 
+	if err := plan.Compile(); err != nil {
+		return nil, fmt.Errorf("failed to compile plan: %w", err)
+	}
+
 	return &plan, nil
 }
@@ -0,0 +1,101 @@
+package plan_test
+
+import (
+	"github.com/mgjules/harvit/plan"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+)
+
+var _ = Describe("Plan", func() {
+	It("should parse and compile a plan", func() {
+		p, err := plan.Parse([]byte(`
+source: https://example.com
+fields:
+  - name: price
+    type: number
+    selector: .price
+    regex: (\d+)
+  - name: posted
+    type: datetime
+    selector: .posted
+    format: d/m/Y
+    timezone: Indian/Mauritius
+  - name: title
+    selectors: [h1.title, h1]
+`))
+		Expect(err).To(BeNil())
+
+		Expect(p.Type).To(Equal("website"))
+		Expect(p.Fields[0].Regexp()).NotTo(BeNil())
+		Expect(p.Fields[1].Location().String()).To(Equal("Indian/Mauritius"))
+		Expect(p.Fields[2].AllSelectors()).To(Equal([]string{"h1.title", "h1"}))
+	})
+
+	DescribeTable("should reject an invalid field",
+		func(field string) {
+			_, err := plan.Parse([]byte(`
+source: https://example.com
+fields:
+  - name: posted
+    type: datetime
+    selector: .posted
+` + field))
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("regex", "    regex: (\\d+\n"),
+		Entry("timezone", "    timezone: Mars/Olympus\n"),
+		Entry("format", "    format: Q\n"),
+		Entry("no match mode", "    no_match: ignore\n"),
+		Entry("locale", "    locale: xx\n"),
+		Entry("separators", "    decimal_separator: \",\"\n    group_separator: \",\"\n"),
+		Entry("long separator", "    decimal_separator: \",,\"\n"),
+		Entry("slice", "    slice: 2-5\n"),
+		Entry("slice bound", "    slice: a:5\n"),
+		Entry("index with slice", "    index: 1\n    slice: 2:5\n"),
+		Entry("first with last", "    first: true\n    last: true\n"),
+		Entry("selector with selectors", "    selectors: [.date]\n"),
+		Entry("limit", "    limit: -1\n"),
+	)
+
+	It("should report the problems of every field", func() {
+		p := plan.Plan{
+			Fields: []plan.Field{
+				{Name: "price", Regex: `(\d+`},
+				{Name: "posted", Timezone: "Mars/Olympus"},
+			},
+			Follow: &plan.Follow{
+				Fields: []plan.Field{
+					{Name: "title", Fields: []plan.Field{{Name: "tags", Slice: "2-5"}}},
+				},
+			},
+		}
+
+		err := p.Compile()
+		Expect(err).To(MatchError(ContainSubstring("failed to compile regex of field price")))
+		Expect(err).To(MatchError(ContainSubstring("failed to load timezone of field posted")))
+		Expect(err).To(MatchError(ContainSubstring("invalid slice of field tags")))
+	})
+
+	DescribeTable("should pick the nodes to harvest",
+		func(field plan.Field, from, to int) {
+			Expect(field.Compile()).To(Succeed())
+
+			f, t := field.Pick(10)
+			Expect(f).To(Equal(from))
+			Expect(t).To(Equal(to))
+		},
+		Entry("every node by default", plan.Field{}, 0, 10),
+		Entry("the first node", plan.Field{First: true}, 0, 1),
+		Entry("the last node", plan.Field{Last: true}, 9, 10),
+		Entry("the node at an index", plan.Field{Index: lo.ToPtr(3)}, 3, 4),
+		Entry("the node at a negative index", plan.Field{Index: lo.ToPtr(-2)}, 8, 9),
+		Entry("no node at an index out of range", plan.Field{Index: lo.ToPtr(10)}, 0, 0),
+		Entry("a slice", plan.Field{Slice: "2:5"}, 2, 5),
+		Entry("a slice with omitted bounds", plan.Field{Slice: "7:"}, 7, 10),
+		Entry("a slice with negative bounds", plan.Field{Slice: "-3:-1"}, 7, 9),
+		Entry("a slice out of range", plan.Field{Slice: "8:20"}, 8, 10),
+		Entry("a limited number of nodes", plan.Field{Limit: 4}, 0, 4),
+		Entry("a limited slice", plan.Field{Slice: "2:", Limit: 3}, 2, 5),
+	)
+})
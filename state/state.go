@@ -0,0 +1,103 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mgjules/harvit/json"
+)
+
+// Store keeps the last result of each plan.
+type Store interface {
+	// Load returns the last result saved for a key, if any.
+	Load(key string) (any, bool, error)
+	// Save saves the result for a key.
+	Save(key string, v any) error
+}
+
+// Key returns the key of a plan file.
+func Key(planFile string) string {
+	abs, err := filepath.Abs(planFile)
+	if err != nil {
+		abs = planFile
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	name := strings.TrimSuffix(filepath.Base(planFile), filepath.Ext(planFile))
+
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+// File is a store keeping each result in a JSON file of a directory.
+type File struct {
+	dir string
+}
+
+// NewFile returns a new File store in a directory, creating it if needed.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return &File{dir: dir}, nil
+}
+
+// Load returns the last result saved for a key, if any.
+func (f *File) Load(key string) (any, bool, error) {
+	raw, err := os.ReadFile(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	return v, true, nil
+}
+
+// Save saves the result for a key.
+// The file is replaced atomically so that a crash never leaves a partial state.
+func (f *File) Save(key string, v any) error {
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(marshaled); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) path(key string) string {
+	return filepath.Join(f.dir, key+".json")
+}
@@ -0,0 +1,71 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mgjules/harvit/state"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File", func() {
+	var (
+		dir   string
+		store *state.File
+	)
+
+	BeforeEach(func() {
+		dir = filepath.Join(GinkgoT().TempDir(), "state")
+
+		var err error
+		store, err = state.NewFile(dir)
+		Expect(err).To(BeNil())
+	})
+
+	It("should create its directory", func() {
+		Expect(dir).To(BeADirectory())
+	})
+
+	It("should not find a key never saved", func() {
+		v, found, err := store.Load("prices")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+		Expect(v).To(BeNil())
+	})
+
+	It("should load the last result saved", func() {
+		Expect(store.Save("prices", map[string]any{"price": 100})).To(Succeed())
+		Expect(store.Save("prices", map[string]any{"price": 90, "tags": []string{"go"}})).To(Succeed())
+
+		v, found, err := store.Load("prices")
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(v).To(Equal(map[string]any{"price": float64(90), "tags": []any{"go"}}))
+
+		// Only the state file is left, without any temporary file.
+		entries, err := os.ReadDir(dir)
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name()).To(Equal("prices.json"))
+	})
+
+	It("should fail to load a corrupted state", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "prices.json"), []byte("{"), 0o600)).To(Succeed())
+
+		_, _, err := store.Load("prices")
+		Expect(err).To(MatchError(ContainSubstring("failed to unmarshal state")))
+	})
+
+	It("should fail to save an unmarshalable result", func() {
+		Expect(store.Save("prices", map[string]any{"price": func() {}})).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Key", func() {
+	It("should be named after the plan file and unique by path", func() {
+		Expect(state.Key("plans/prices.yml")).To(HavePrefix("prices-"))
+		Expect(state.Key("plans/prices.yml")).To(Equal(state.Key("plans/prices.yml")))
+		Expect(state.Key("plans/prices.yml")).NotTo(Equal(state.Key("other/prices.yml")))
+	})
+})
@@ -44,3 +44,37 @@ func Transform(ctx context.Context, transformer string, fields []plan.Field, dat
 
 	return vm.Get("data").Export(), nil
 }
+
+// Match evaluates a JavaScript condition against data e.g "price < 100".
+// The keys of an object are available as variables, the whole data as "data".
+func Match(ctx context.Context, condition string, data any) (bool, error) {
+	vm := goja.New()
+
+	go func() {
+		select {
+		case <-time.After(2 * time.Second):
+			vm.Interrupt("halt")
+		case <-ctx.Done():
+			vm.Interrupt("halt")
+		}
+	}()
+
+	if m, ok := data.(map[string]any); ok {
+		for k, v := range m {
+			if err := vm.Set(k, v); err != nil {
+				return false, fmt.Errorf("failed to set %s: %w", k, err)
+			}
+		}
+	}
+
+	if err := vm.Set("data", data); err != nil {
+		return false, fmt.Errorf("failed to set data: %w", err)
+	}
+
+	v, err := vm.RunString(condition)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition: %w", err)
+	}
+
+	return v.ToBoolean(), nil
+}